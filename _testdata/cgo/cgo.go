@@ -0,0 +1,13 @@
+package cgo
+
+/*
+#cgo CFLAGS: -I${SRCDIR}/include -DNAME="foo bar"
+#cgo linux,amd64 darwin LDFLAGS: -lm
+#include <math.h>
+*/
+// #cgo pkg-config: libpng
+import "C"
+
+func Sqrt(x float64) float64 {
+	return float64(C.sqrt(C.double(x)))
+}
@@ -0,0 +1,9 @@
+//go:build !cgo
+
+package cgo
+
+import "math"
+
+func Sqrt(x float64) float64 {
+	return math.Sqrt(x)
+}
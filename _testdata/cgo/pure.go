@@ -0,0 +1,3 @@
+package cgo
+
+func Square(x float64) float64 { return x * x }
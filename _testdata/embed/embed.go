@@ -0,0 +1,21 @@
+package embed
+
+import (
+	_ "embed"
+	assets "embed"
+)
+
+//go:embed hello.txt
+var hello string
+
+//go:embed "hello.txt"
+var helloBytes []byte
+
+//go:embed static
+//go:embed all:static/sub *.txt
+var static assets.FS
+
+//go:embed missing.txt
+var missing string
+
+var notEmbed string
@@ -0,0 +1,11 @@
+package generated
+
+//go:generate stringer -type=Color
+
+// Color is a color.
+type Color int
+
+const (
+	Red Color = iota
+	Green
+)
@@ -0,0 +1,8 @@
+package pkgset_test
+
+import "fmt"
+
+func ExampleClient_Do() {
+	fmt.Println("done")
+	// Output: done
+}
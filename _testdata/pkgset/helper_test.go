@@ -0,0 +1,11 @@
+package pkgset
+
+import "testing"
+
+type fakeClient struct{}
+
+func (c *Client) reset() {}
+
+var testMax = Max
+
+func TestDo(t *testing.T) {}
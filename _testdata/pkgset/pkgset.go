@@ -0,0 +1,9 @@
+package pkgset
+
+// Client is a client.
+type Client struct{}
+
+// Do does something.
+func (c *Client) Do() error { return nil }
+
+const Max = 10
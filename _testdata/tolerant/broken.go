@@ -0,0 +1,11 @@
+package tolerant
+
+type Broken struct {
+	Value int
+	Tag   string 123
+}
+
+func Oops( {
+}
+
+var After = 1
@@ -0,0 +1,7 @@
+package tolerant
+
+type Good struct {
+	Name string
+}
+
+func Hello() string { return "hello" }
@@ -0,0 +1,18 @@
+package typecheck
+
+import "time"
+
+type Client struct {
+	Name    string
+	Timeout time.Duration
+}
+
+func (c *Client) Do(n int) error { return nil }
+
+type Doer interface {
+	Do(n int) error
+}
+
+func New(name string) *Client { return &Client{Name: name} }
+
+const Max = 10
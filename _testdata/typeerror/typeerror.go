@@ -0,0 +1,7 @@
+package typeerror
+
+type Foo struct {
+	Bar Undefined
+}
+
+func Baz() int { return 0 }
@@ -0,0 +1,50 @@
+package unused
+
+import "fmt"
+
+type client struct {
+	name    string
+	retries int
+	unusedF int
+}
+
+type orphan struct {
+	next *orphan
+}
+
+func (o *orphan) clone() *orphan { return &orphan{next: o.next} }
+
+const (
+	maxRetries = 3
+	deadConst  = 4
+)
+
+var deadVar = fmt.Sprint(deadConst)
+
+var _ = deadVar
+
+func newClient(name string) *client {
+	return &client{name: name, retries: maxRetries}
+}
+
+func (c *client) String() string { return c.name }
+
+func onlyTested() int { return 1 }
+
+func recursive(n int) int {
+	if n == 0 {
+		return 0
+	}
+	return recursive(n - 1)
+}
+
+func Exported() fmt.Stringer { return newClient("foo") }
+
+func init() {}
+
+type point struct {
+	x, y int
+}
+
+// Origin uses all fields of point without keys.
+var Origin = point{0, 0}
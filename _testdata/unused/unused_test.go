@@ -0,0 +1,9 @@
+package unused
+
+import "testing"
+
+func TestOnlyTested(t *testing.T) {
+	if onlyTested() != 1 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,140 @@
+package srcdom
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// APIChange is a change of exported API between two snapshots of a
+// package.
+type APIChange struct {
+	*Change
+
+	// Compatible is true when the change keeps compatibility, according
+	// to Go's compatibility rules.
+	Compatible bool
+}
+
+// APIDiff is a result of Diff.
+type APIDiff struct {
+	Changes []*APIChange
+}
+
+// Diff compares exported API of two snapshots of a package.  Changes are
+// classified as compatible or breaking.  Removal of exported declarations
+// and changes of types or signatures are breaking.  Additions are
+// compatible, except methods added to interfaces.
+func Diff(old, new *Package) *APIDiff {
+	d := &APIDiff{}
+	for _, c := range comparePackages(old, new) {
+		if !isPublicChange(c) {
+			continue
+		}
+		d.Changes = append(d.Changes, &APIChange{
+			Change:     c,
+			Compatible: isCompatibleChange(old, new, c),
+		})
+	}
+	return d
+}
+
+func isPublicChange(c *Change) bool {
+	switch c.Kind {
+	case PackageAdded, PackageRemoved:
+		return true
+	case EmbedAdded, EmbedRemoved:
+		// names of embeds are like "*Base" or "io.Reader".
+		typeName, embed, _ := strings.Cut(c.Name, ".")
+		return isPublicName(typeName) && isPublicName(embedBaseName(embed))
+	}
+	for _, name := range strings.Split(c.Name, ".") {
+		if !isPublicName(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// embedBaseName returns an identifier of an embedded type, like "Reader"
+// for "*io.Reader" or "List" for "List[int]".
+func embedBaseName(embed string) string {
+	embed = strings.TrimPrefix(embed, "*")
+	if i := strings.Index(embed, "["); i >= 0 {
+		embed = embed[:i]
+	}
+	return embed[strings.LastIndex(embed, ".")+1:]
+}
+
+func isCompatibleChange(old, new *Package, c *Change) bool {
+	switch c.Kind {
+	case PackageAdded, TypeAdded, FuncAdded, ValueAdded, FieldAdded:
+		return true
+	case MethodAdded, EmbedAdded:
+		// adding methods to interfaces breaks its implementations.
+		typeName, _, _ := strings.Cut(c.Name, ".")
+		typ, ok := new.Type(typeName)
+		return !ok || !typ.IsInterface
+	case FieldChanged:
+		// changes of tags are compatible.
+		typeName, fieldName, _ := strings.Cut(c.Name, ".")
+		ot, _ := old.Type(typeName)
+		nt, _ := new.Type(typeName)
+		of, _ := ot.Field(fieldName)
+		nf, _ := nt.Field(fieldName)
+		return of.Type == nf.Type
+	case MethodChanged:
+		// changing a pointer receiver to a value receiver only adds the
+		// method to the method set of the value type.
+		typeName, methodName, _ := strings.Cut(c.Name, ".")
+		ot, _ := old.Type(typeName)
+		nt, _ := new.Type(typeName)
+		om, _ := ot.Method(methodName)
+		nm, _ := nt.Method(methodName)
+		return om.signature() == nm.signature() && om.pointerRecv() && !nm.pointerRecv()
+	case ValueChanged:
+		// changes of literals are compatible.
+		ov, _ := old.Value(c.Name)
+		nv, _ := new.Value(c.Name)
+		return ov.TypeExpr == nv.TypeExpr && ov.IsConst == nv.IsConst
+	default:
+		return false
+	}
+}
+
+// Breaking checks the diff includes breaking changes or not.
+func (d *APIDiff) Breaking() bool {
+	for _, c := range d.Changes {
+		if !c.Compatible {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteReport writes a report of the diff.  Incompatible changes are
+// reported first, then compatible changes.
+func (d *APIDiff) WriteReport(w io.Writer) error {
+	for _, section := range []struct {
+		title      string
+		compatible bool
+	}{
+		{"Incompatible changes:", false},
+		{"Compatible changes:", true},
+	} {
+		var lines []string
+		for _, c := range d.Changes {
+			if c.Compatible == section.compatible {
+				lines = append(lines, "- "+c.String())
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		_, err := fmt.Fprintf(w, "%s\n%s\n", section.title, strings.Join(lines, "\n"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
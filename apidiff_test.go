@@ -0,0 +1,185 @@
+package srcdom_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func TestDiff(t *testing.T) {
+	old, err := srcdom.ReadSource("old.go", []byte(`package foo
+
+type Client struct {
+	Name    string `+"`json:\"name\"`"+`
+	Timeout int
+	secret  string
+}
+
+func (c *Client) Do(n int) error { return nil }
+
+type Doer interface {
+	Do(n int) error
+}
+
+func New() *Client { return nil }
+
+func Remove() {}
+
+const Version = "1.0"
+
+var Default int
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := srcdom.ReadSource("new.go", []byte(`package foo
+
+type Client struct {
+	Name    string `+"`json:\"name,omitempty\"`"+`
+	Timeout int64
+	Retry   int
+}
+
+func (c *Client) Do(n int) error { return nil }
+
+func (c *Client) Close() error { return nil }
+
+type Doer interface {
+	Do(n int) error
+	Close() error
+}
+
+func New(name string) *Client { return nil }
+
+func Added() {}
+
+const Version = "2.0"
+
+var Default string
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := srcdom.Diff(old, new)
+	if !d.Breaking() {
+		t.Error("diff should be breaking")
+	}
+	bb := &bytes.Buffer{}
+	if err := d.WriteReport(bb); err != nil {
+		t.Fatal(err)
+	}
+	want := `Incompatible changes:
+- value changed: Default (var int -> var string)
+- func changed: New (() *Client -> (string) *Client)
+- func removed: Remove
+- field changed: Client.Timeout (int -> int64)
+- method added: Doer.Close
+Compatible changes:
+- value changed: Version (const = "1.0" -> const = "2.0")
+- func added: Added
+- field changed: Client.Name (string "json:\"name\"" -> string "json:\"name,omitempty\"")
+- field added: Client.Retry
+- method added: Client.Close
+`
+	if diff := cmp.Diff(want, bb.String()); diff != "" {
+		t.Errorf("unmatch report: -want +got\n%s", diff)
+	}
+
+	if srcdom.Diff(old, old).Breaking() {
+		t.Error("same packages should not be breaking")
+	}
+}
+
+func TestDiffEmbeds(t *testing.T) {
+	old, err := srcdom.ReadSource("old.go", []byte(`package foo
+
+import "io"
+
+type Client struct {
+	io.Reader
+}
+
+type T struct {
+	*Base
+	base
+}
+
+type Base struct{}
+
+type base struct{}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := srcdom.ReadSource("new.go", []byte(`package foo
+
+type Client struct{}
+
+type T struct {
+	List[int]
+}
+
+type List[E any] []E
+
+type Base struct{}
+
+type base struct{}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := &bytes.Buffer{}
+	if err := srcdom.Diff(old, new).WriteReport(bb); err != nil {
+		t.Fatal(err)
+	}
+	want := `Incompatible changes:
+- embed removed: Client.io.Reader
+- embed removed: T.*Base
+Compatible changes:
+- type added: List
+- embed added: T.List[int]
+`
+	if diff := cmp.Diff(want, bb.String()); diff != "" {
+		t.Errorf("unmatch report: -want +got\n%s", diff)
+	}
+}
+
+func TestDiffReceivers(t *testing.T) {
+	old, err := srcdom.ReadSource("old.go", []byte(`package foo
+
+type Client struct{}
+
+func (c Client) Get() error { return nil }
+
+func (c *Client) Put() error { return nil }
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := srcdom.ReadSource("new.go", []byte(`package foo
+
+type Client struct{}
+
+func (c *Client) Get() error { return nil }
+
+func (c Client) Put() error { return nil }
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := &bytes.Buffer{}
+	if err := srcdom.Diff(old, new).WriteReport(bb); err != nil {
+		t.Fatal(err)
+	}
+	want := `Incompatible changes:
+- method changed: Client.Get ((Client) () error -> (*Client) () error)
+Compatible changes:
+- method changed: Client.Put ((*Client) () error -> (Client) () error)
+`
+	if diff := cmp.Diff(want, bb.String()); diff != "" {
+		t.Errorf("unmatch report: -want +got\n%s", diff)
+	}
+}
@@ -0,0 +1,79 @@
+package srcdom
+
+import (
+	"sort"
+	"strings"
+)
+
+// APISurface returns a sorted listing of exported API of the package, in
+// a format similar to Go's api/go1.*.txt.  For example:
+//
+//	pkg foo, func New(string) *Client
+//	pkg foo, method (*Client) Do(int) error
+//	pkg foo, type Client struct
+//	pkg foo, type Client struct, Timeout time.Duration
+func (p *Package) APISurface() []string {
+	prefix := "pkg " + p.Name + ", "
+	var lines []string
+	add := func(s string) {
+		lines = append(lines, prefix+s)
+	}
+
+	for _, v := range p.Values {
+		if !v.IsPublic() {
+			continue
+		}
+		add(v.apiString())
+	}
+	for _, fn := range p.Funcs {
+		if !fn.IsPublic() {
+			continue
+		}
+		add("func " + fn.Name + fn.signature())
+	}
+	for _, typ := range p.Types {
+		if !typ.IsPublic() || !typ.Defined {
+			continue
+		}
+		decl := "type " + typ.Name + " " + typ.describe()
+		add(decl)
+		for _, name := range typ.Embeds {
+			add(decl + ", embedded " + name)
+		}
+		for _, f := range typ.Fields {
+			if !isPublicName(f.Name) {
+				continue
+			}
+			add(decl + ", " + f.Name + " " + f.Type)
+		}
+		for _, m := range typ.Methods {
+			if !m.IsPublic() {
+				continue
+			}
+			if typ.IsInterface {
+				add(decl + ", " + m.Name + m.signature())
+				continue
+			}
+			add("method (" + m.Recv + ") " + m.Name + m.signature())
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func (v *Value) apiString() string {
+	b := &strings.Builder{}
+	if v.IsConst {
+		b.WriteString("const ")
+	} else {
+		b.WriteString("var ")
+	}
+	b.WriteString(v.Name)
+	switch {
+	case v.TypeExpr != "":
+		b.WriteString(" " + v.TypeExpr)
+	case v.Literal != nil:
+		b.WriteString(" = " + v.Literal.Value)
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package srcdom_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func TestAPISurface(t *testing.T) {
+	pkg, err := srcdom.ReadSource("foo.go", []byte(`package foo
+
+import (
+	"io"
+	"time"
+)
+
+type Base struct{}
+
+type Client struct {
+	Base
+	Timeout    time.Duration
+	Host, Port string
+	secret     string
+}
+
+func (c *Client) Do(n int) error { return nil }
+
+func (c Client) String() string { return "" }
+
+func (c *Client) reset() {}
+
+type Doer interface {
+	io.Closer
+	Do(n int) error
+}
+
+type ID int
+
+type Alias = Client
+
+type private struct{}
+
+func (private) Exported() {}
+
+func New(name string) *Client { return nil }
+
+func helper() {}
+
+const Max = 10
+
+const Timeout time.Duration = 3
+
+var Default *Client
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"pkg foo, const Max = 10",
+		"pkg foo, const Timeout time.Duration",
+		"pkg foo, func New(string) *Client",
+		"pkg foo, method (*Client) Do(int) error",
+		"pkg foo, method (Client) String() string",
+		"pkg foo, type Alias = Client",
+		"pkg foo, type Base struct",
+		"pkg foo, type Client struct",
+		"pkg foo, type Client struct, Host string",
+		"pkg foo, type Client struct, Port string",
+		"pkg foo, type Client struct, Timeout time.Duration",
+		"pkg foo, type Client struct, embedded Base",
+		"pkg foo, type Doer interface",
+		"pkg foo, type Doer interface, Do(int) error",
+		"pkg foo, type Doer interface, embedded io.Closer",
+		"pkg foo, type ID int",
+		"pkg foo, var Default *Client",
+	}
+	if d := cmp.Diff(want, pkg.APISurface()); d != "" {
+		t.Errorf("unmatch APISurface(): -want +got\n%s", d)
+	}
+}
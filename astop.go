@@ -20,33 +20,39 @@ func baseTypeName(x ast.Expr) (name string, imported bool) {
 		}
 	case *ast.StarExpr:
 		return baseTypeName(typ.X)
+	case *ast.IndexExpr:
+		// generic type with a type parameter
+		return baseTypeName(typ.X)
+	case *ast.IndexListExpr:
+		// generic type with type parameters
+		return baseTypeName(typ.X)
 	}
 	return
 }
 
-func typeString(x ast.Expr) string {
+func (p *Parser) typeString(x ast.Expr) string {
 	switch typ := x.(type) {
 	case *ast.Ident:
 		return typ.Name
 	case *ast.SelectorExpr:
 		if _, ok := typ.X.(*ast.Ident); ok {
-			return typeString(typ.X) + "." + typ.Sel.Name
+			return p.typeString(typ.X) + "." + typ.Sel.Name
 		}
 	case *ast.StarExpr:
-		return "*" + typeString(typ.X)
+		return "*" + p.typeString(typ.X)
 	case *ast.FuncType:
-		fn := toFunc("", typ)
+		fn := p.toFunc("", typ)
 		b := &strings.Builder{}
 		b.WriteString("func (" + typesString(fn.Params) + ")")
 		fn.writeResults(b)
 		return b.String()
 
 	case *ast.Ellipsis:
-		return "..." + typeString(typ.Elt)
+		return "..." + p.typeString(typ.Elt)
 	case *ast.ArrayType:
-		return "[]" + typeString(typ.Elt)
+		return "[]" + p.typeString(typ.Elt)
 	case *ast.MapType:
-		return "map[" + typeString(typ.Key) + "]" + typeString(typ.Value)
+		return "map[" + p.typeString(typ.Key) + "]" + p.typeString(typ.Value)
 	case *ast.StructType:
 		if typ.Fields == nil || len(typ.Fields.List) == 0 {
 			return "struct{}"
@@ -59,7 +65,7 @@ func typeString(x ast.Expr) string {
 			}
 			b.WriteString(firstName(f.Names))
 			b.WriteString(" ")
-			b.WriteString(typeString(f.Type))
+			b.WriteString(p.typeString(f.Type))
 		}
 		b.WriteString(" }")
 		return b.String()
@@ -76,12 +82,13 @@ func typeString(x ast.Expr) string {
 			}
 			switch mTyp := m.Type.(type) {
 			case *ast.FuncType:
-				fn := toFunc("", mTyp)
+				fn := p.toFunc("", mTyp)
 				b.WriteString(firstName(m.Names))
 				b.WriteString("(" + typesString(fn.Params) + ")")
 				fn.writeResults(b)
 			default:
-				panic(fmt.Sprintf("not supported fields in unnamed interface type: %T", m.Type))
+				// TypeElem
+				b.WriteString(p.typeString(m.Type))
 			}
 		}
 		b.WriteString(" }")
@@ -99,32 +106,32 @@ func typeString(x ast.Expr) string {
 		default:
 			panic(fmt.Sprintf("illegal channel direction (ast.ChanDir): %d", typ.Dir))
 		}
-		return chanLabel + " " + typeString(typ.Value)
+		return chanLabel + " " + p.typeString(typ.Value)
 
 	case *ast.BinaryExpr:
-		return typeString(typ.X) + " " + typ.Op.String() + " " + typeString(typ.Y)
+		return p.typeString(typ.X) + " " + typ.Op.String() + " " + p.typeString(typ.Y)
 
 	case *ast.UnaryExpr:
-		return typ.Op.String() + typeString(typ.X)
+		return typ.Op.String() + p.typeString(typ.X)
 
 	case *ast.IndexExpr:
-		return typeString(typ.X) + "[" + typeString(typ.Index) + "]"
+		return p.typeString(typ.X) + "[" + p.typeString(typ.Index) + "]"
 
 	case *ast.IndexListExpr:
 		b := &strings.Builder{}
-		b.WriteString(typeString(typ.X))
+		b.WriteString(p.typeString(typ.X))
 		b.WriteRune('[')
 		for i, expr := range typ.Indices {
 			if i > 0 {
 				b.WriteString(", ")
 			}
-			b.WriteString(typeString(expr))
+			b.WriteString(p.typeString(expr))
 		}
 		b.WriteRune(']')
 		return b.String()
 
 	default:
-		warnf("typeString doesn't support: %T", typ)
+		p.warn(x, CodeUnsupportedExpr, fmt.Sprintf("typeString doesn't support: %T", typ))
 	}
 	return ""
 }
@@ -136,8 +143,8 @@ func firstName(names []*ast.Ident) string {
 	return names[0].Name
 }
 
-func toVar(f *ast.Field) []*Var {
-	typ := typeString(f.Type)
+func (p *Parser) toVar(f *ast.Field) []*Var {
+	typ := p.typeString(f.Type)
 	if len(f.Names) == 0 {
 		return []*Var{{Name: "", Type: typ}}
 	}
@@ -148,13 +155,13 @@ func toVar(f *ast.Field) []*Var {
 	return vars
 }
 
-func toVarArray(fl *ast.FieldList) []*Var {
+func (p *Parser) toVarArray(fl *ast.FieldList) []*Var {
 	if fl == nil || len(fl.List) == 0 {
 		return nil
 	}
 	vars := make([]*Var, 0, len(fl.List))
 	for _, f := range fl.List {
-		vars = append(vars, toVar(f)...)
+		vars = append(vars, p.toVar(f)...)
 	}
 	return vars
 }
@@ -173,11 +180,11 @@ func typesString(vars []*Var) string {
 	return b.String()
 }
 
-func toFunc(name string, funcType *ast.FuncType) *Func {
+func (p *Parser) toFunc(name string, funcType *ast.FuncType) *Func {
 	f := &Func{Name: name}
 	if funcType != nil {
-		f.Params = toVarArray(funcType.Params)
-		f.Results = toVarArray(funcType.Results)
+		f.Params = p.toVarArray(funcType.Params)
+		f.Results = p.toVarArray(funcType.Results)
 	}
 	return f
 }
@@ -0,0 +1,730 @@
+package srcdom
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// RefKind is a kind of Ref.
+type RefKind int
+
+// Kinds of Ref.
+const (
+	// RefUnresolved is a reference to a name, which is not declared in
+	// the package, like a builtin function.
+	RefUnresolved RefKind = iota
+	RefFunc
+	RefMethod
+	RefField
+	RefType
+	RefValue
+	// RefSelector is a selector whose operand type is unknown, like
+	// "x.Name".  Name of the Ref is only the selector.
+	RefSelector
+)
+
+var refKindNames = map[RefKind]string{
+	RefUnresolved: "unresolved",
+	RefFunc:       "func",
+	RefMethod:     "method",
+	RefField:      "field",
+	RefType:       "type",
+	RefValue:      "value",
+	RefSelector:   "selector",
+}
+
+func (k RefKind) String() string {
+	if s, ok := refKindNames[k]; ok {
+		return s
+	}
+	return "RefKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Ref is a reference from a declaration to a package-level declaration.
+// References are resolved syntactically within the package.
+type Ref struct {
+	Kind RefKind
+
+	// Name is a name of the referenced declaration.  Methods and fields
+	// are named in the form of "{Type}.{Name}".
+	Name string
+
+	// Call is true when the reference is called.
+	Call bool
+
+	// Unkeyed is true for a reference to a struct type by a composite
+	// literal without keys, like "T{1, 2}", which uses all fields.
+	Unkeyed bool
+
+	Pos token.Position
+}
+
+// Calls returns references to functions and methods, which called from
+// the function, in order of appearance.  It requires Config.ScanBodies.
+func (fn *Func) Calls() []*Ref {
+	var calls []*Ref
+	for _, r := range fn.Refs {
+		if r.Call && (r.Kind == RefFunc || r.Kind == RefMethod) {
+			calls = append(calls, r)
+		}
+	}
+	return calls
+}
+
+// CallersOf returns functions and methods which call a function or a
+// method.  Methods are named in the form of "{Type}.{Method}".  It
+// requires Config.ScanBodies.
+func (p *Package) CallersOf(name string) []*Func {
+	var callers []*Func
+	p.eachFunc(func(fn *Func) {
+		for _, r := range fn.Calls() {
+			if r.Name == name {
+				callers = append(callers, fn)
+				return
+			}
+		}
+	})
+	return callers
+}
+
+// eachFunc calls f for all functions and methods in the package.
+func (p *Package) eachFunc(f func(fn *Func)) {
+	for _, fn := range p.Funcs {
+		f(fn)
+	}
+	for _, typ := range p.Types {
+		for _, m := range typ.Methods {
+			f(m)
+		}
+	}
+}
+
+// eachRefs calls f with references of all declarations in the package.
+// owner is a name of the declaration which has the references.
+func (p *Package) eachRefs(f func(owner string, refs []*Ref)) {
+	for _, v := range p.Values {
+		f(v.Name, v.Refs)
+	}
+	for _, fn := range p.Funcs {
+		f(fn.Name, fn.Refs)
+	}
+	for _, typ := range p.Types {
+		f(typ.Name, typ.Refs)
+		for _, m := range typ.Methods {
+			f(typ.Name+"."+m.Name, m.Refs)
+		}
+	}
+}
+
+// ResolveRefs resolves kinds of references in declarations.  Reading
+// functions and UpdateFile call this, so it is needed only when using
+// Parser directly with ScanBodies.
+func (p *Package) ResolveRefs() {
+	p.eachRefs(func(_ string, refs []*Ref) {
+		for _, r := range refs {
+			p.resolveRef(r)
+		}
+	})
+}
+
+func (p *Package) resolveRef(r *Ref) {
+	if r.Kind == RefSelector {
+		return
+	}
+	r.Kind = RefUnresolved
+	typeName, member, ok := strings.Cut(r.Name, ".")
+	if !ok {
+		if _, ok := p.Func(r.Name); ok {
+			r.Kind = RefFunc
+		} else if _, ok := p.Type(r.Name); ok {
+			r.Kind = RefType
+		} else if _, ok := p.Value(r.Name); ok {
+			r.Kind = RefValue
+		}
+		return
+	}
+	typ, ok := p.Type(typeName)
+	if !ok {
+		return
+	}
+	if _, ok := typ.Method(member); ok {
+		r.Kind = RefMethod
+	} else if _, ok := typ.Field(member); ok {
+		r.Kind = RefField
+	}
+}
+
+// bodyScanner collects references in a function body.  Local names are
+// tracked by scopes, and each name has a base type name when it is known.
+type bodyScanner struct {
+	p     *Parser
+	scope *bodyScope
+	refs  []*Ref
+}
+
+type bodyScope struct {
+	parent *bodyScope
+	names  map[string]string
+}
+
+func (s *bodyScope) lookup(name string) (string, bool) {
+	for ; s != nil; s = s.parent {
+		if typ, ok := s.names[name]; ok {
+			return typ, true
+		}
+	}
+	return "", false
+}
+
+func (bs *bodyScanner) push() {
+	bs.scope = &bodyScope{parent: bs.scope, names: map[string]string{}}
+}
+
+func (bs *bodyScanner) pop() {
+	bs.scope = bs.scope.parent
+}
+
+func (bs *bodyScanner) define(name, typeName string) {
+	if name == "" || name == "_" {
+		return
+	}
+	bs.scope.names[name] = typeName
+}
+
+func (bs *bodyScanner) addRef(kind RefKind, name string, pos token.Pos) *Ref {
+	r := &Ref{Kind: kind, Name: name, Pos: bs.p.position(pos)}
+	bs.refs = append(bs.refs, r)
+	return r
+}
+
+// localTypeName returns a base type name of the expression, if it is a
+// type in the package.
+func (bs *bodyScanner) localTypeName(x ast.Expr) string {
+	n, imp := baseTypeName(x)
+	if imp {
+		return ""
+	}
+	if _, local := bs.scope.lookup(n); local {
+		return ""
+	}
+	return n
+}
+
+// defineFields defines names in a field list, like parameters.  Types in
+// the list are collected as references when withRefs is true.
+func (bs *bodyScanner) defineFields(fl *ast.FieldList, withRefs bool) {
+	if fl == nil {
+		return
+	}
+	for _, f := range fl.List {
+		if withRefs {
+			bs.expr(f.Type)
+		}
+		typeName := bs.localTypeName(f.Type)
+		for _, n := range f.Names {
+			bs.define(n.Name, typeName)
+		}
+	}
+}
+
+// defineTypeParams defines names of type parameters, and collects
+// references in those constraints.
+func (bs *bodyScanner) defineTypeParams(fl *ast.FieldList) {
+	if fl == nil {
+		return
+	}
+	for _, f := range fl.List {
+		for _, n := range f.Names {
+			bs.define(n.Name, "")
+		}
+	}
+	bs.fieldTypes(fl)
+}
+
+// scanFunc collects references in a signature and a body of a function
+// declaration.
+func (p *Parser) scanFunc(fun *ast.FuncDecl) []*Ref {
+	bs := &bodyScanner{p: p}
+	bs.push()
+	if fun.Recv != nil {
+		// type parameters of the receiver are names in the receiver type.
+		if len(fun.Recv.List) > 0 {
+			for _, x := range recvTypeParams(fun.Recv.List[0].Type) {
+				if id, ok := x.(*ast.Ident); ok {
+					bs.define(id.Name, "")
+				}
+			}
+		}
+		bs.defineFields(fun.Recv, false)
+	}
+	bs.defineTypeParams(fun.Type.TypeParams)
+	bs.defineFields(fun.Type.Params, true)
+	bs.defineFields(fun.Type.Results, true)
+	if fun.Body != nil {
+		bs.stmt(fun.Body)
+	}
+	return bs.refs
+}
+
+func recvTypeParams(x ast.Expr) []ast.Expr {
+	if star, ok := x.(*ast.StarExpr); ok {
+		x = star.X
+	}
+	switch x := x.(type) {
+	case *ast.IndexExpr:
+		return []ast.Expr{x.Index}
+	case *ast.IndexListExpr:
+		return x.Indices
+	}
+	return nil
+}
+
+// scanValue collects references in the type and the value of i-th name
+// in a value spec.
+func (p *Parser) scanValue(s *ast.ValueSpec, i int) []*Ref {
+	bs := &bodyScanner{p: p}
+	bs.push()
+	bs.expr(s.Type)
+	switch {
+	case len(s.Values) == len(s.Names):
+		bs.expr(s.Values[i])
+	default:
+		// multiple values from a function call.
+		bs.exprs(s.Values)
+	}
+	return bs.refs
+}
+
+// scanType collects references in a type definition.
+func (p *Parser) scanType(s *ast.TypeSpec) []*Ref {
+	bs := &bodyScanner{p: p}
+	bs.push()
+	bs.define(s.Name.Name, "")
+	bs.defineTypeParams(s.TypeParams)
+	bs.expr(s.Type)
+	return bs.refs
+}
+
+func (bs *bodyScanner) stmts(list []ast.Stmt) {
+	for _, s := range list {
+		bs.stmt(s)
+	}
+}
+
+func (bs *bodyScanner) exprs(list []ast.Expr) {
+	for _, x := range list {
+		bs.expr(x)
+	}
+}
+
+func (bs *bodyScanner) stmt(s ast.Stmt) {
+	switch s := s.(type) {
+	case nil:
+	case *ast.BlockStmt:
+		if s == nil {
+			return
+		}
+		bs.push()
+		bs.stmts(s.List)
+		bs.pop()
+	case *ast.DeclStmt:
+		bs.decl(s.Decl)
+	case *ast.AssignStmt:
+		bs.exprs(s.Rhs)
+		if s.Tok != token.DEFINE {
+			bs.exprs(s.Lhs)
+			return
+		}
+		for i, x := range s.Lhs {
+			id, ok := x.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			typeName := ""
+			if len(s.Lhs) == len(s.Rhs) {
+				typeName = bs.exprTypeName(s.Rhs[i])
+			}
+			bs.define(id.Name, typeName)
+		}
+	case *ast.ExprStmt:
+		bs.expr(s.X)
+	case *ast.SendStmt:
+		bs.expr(s.Chan)
+		bs.expr(s.Value)
+	case *ast.IncDecStmt:
+		bs.expr(s.X)
+	case *ast.GoStmt:
+		bs.expr(s.Call)
+	case *ast.DeferStmt:
+		bs.expr(s.Call)
+	case *ast.ReturnStmt:
+		bs.exprs(s.Results)
+	case *ast.LabeledStmt:
+		bs.stmt(s.Stmt)
+	case *ast.IfStmt:
+		bs.push()
+		bs.stmt(s.Init)
+		bs.expr(s.Cond)
+		bs.stmt(s.Body)
+		bs.stmt(s.Else)
+		bs.pop()
+	case *ast.SwitchStmt:
+		bs.push()
+		bs.stmt(s.Init)
+		bs.expr(s.Tag)
+		bs.stmt(s.Body)
+		bs.pop()
+	case *ast.TypeSwitchStmt:
+		bs.push()
+		bs.stmt(s.Init)
+		bs.stmt(s.Assign)
+		bs.stmt(s.Body)
+		bs.pop()
+	case *ast.CaseClause:
+		bs.exprs(s.List)
+		bs.push()
+		bs.stmts(s.Body)
+		bs.pop()
+	case *ast.SelectStmt:
+		bs.stmt(s.Body)
+	case *ast.CommClause:
+		bs.push()
+		bs.stmt(s.Comm)
+		bs.stmts(s.Body)
+		bs.pop()
+	case *ast.ForStmt:
+		bs.push()
+		bs.stmt(s.Init)
+		bs.expr(s.Cond)
+		bs.stmt(s.Post)
+		bs.stmt(s.Body)
+		bs.pop()
+	case *ast.RangeStmt:
+		bs.expr(s.X)
+		bs.push()
+		if s.Tok == token.DEFINE {
+			for _, x := range []ast.Expr{s.Key, s.Value} {
+				if id, ok := x.(*ast.Ident); ok {
+					bs.define(id.Name, "")
+				}
+			}
+		} else {
+			bs.expr(s.Key)
+			bs.expr(s.Value)
+		}
+		bs.stmt(s.Body)
+		bs.pop()
+	}
+}
+
+func (bs *bodyScanner) decl(d ast.Decl) {
+	gd, ok := d.(*ast.GenDecl)
+	if !ok {
+		return
+	}
+	for _, spec := range gd.Specs {
+		switch s := spec.(type) {
+		case *ast.ValueSpec:
+			bs.expr(s.Type)
+			bs.exprs(s.Values)
+			typeName := ""
+			if s.Type != nil {
+				typeName = bs.localTypeName(s.Type)
+			}
+			for i, n := range s.Names {
+				t := typeName
+				if t == "" && len(s.Names) == len(s.Values) {
+					t = bs.exprTypeName(s.Values[i])
+				}
+				bs.define(n.Name, t)
+			}
+		case *ast.TypeSpec:
+			bs.define(s.Name.Name, "")
+			bs.expr(s.Type)
+		}
+	}
+}
+
+// exprTypeName guesses a base type name of the expression, for composite
+// literals, conversions and new().
+func (bs *bodyScanner) exprTypeName(x ast.Expr) string {
+	switch x := x.(type) {
+	case *ast.CompositeLit:
+		return bs.localTypeName(x.Type)
+	case *ast.UnaryExpr:
+		if x.Op == token.AND {
+			return bs.exprTypeName(x.X)
+		}
+	case *ast.ParenExpr:
+		return bs.exprTypeName(x.X)
+	case *ast.CallExpr:
+		if len(x.Args) != 1 {
+			break
+		}
+		if id, ok := x.Fun.(*ast.Ident); ok && id.Name == "new" {
+			if _, local := bs.scope.lookup("new"); !local {
+				return bs.localTypeName(x.Args[0])
+			}
+		}
+		// it may be a conversion.  when not, the name doesn't match with
+		// any types at resolution.
+		return bs.localTypeName(x.Fun)
+	}
+	return ""
+}
+
+// callee collects references of the function part of a call, and marks
+// the callee as called.
+func (bs *bodyScanner) callee(x ast.Expr) {
+	x = unparen(x)
+	var args []ast.Expr
+	switch y := x.(type) {
+	case *ast.IndexExpr:
+		x, args = unparen(y.X), []ast.Expr{y.Index}
+	case *ast.IndexListExpr:
+		x, args = unparen(y.X), y.Indices
+	}
+	n := len(bs.refs)
+	bs.expr(x)
+	switch x.(type) {
+	case *ast.Ident, *ast.SelectorExpr:
+		if len(bs.refs) > n {
+			bs.refs[len(bs.refs)-1].Call = true
+		}
+	}
+	bs.exprs(args)
+}
+
+func (bs *bodyScanner) expr(x ast.Expr) {
+	switch x := x.(type) {
+	case nil:
+	case *ast.Ident:
+		if _, local := bs.scope.lookup(x.Name); local || x.Name == "_" {
+			return
+		}
+		bs.addRef(RefUnresolved, x.Name, x.Pos())
+	case *ast.SelectorExpr:
+		if id, ok := methodExprType(x.X); ok {
+			if _, local := bs.scope.lookup(id.Name); !local && !bs.isImportName(id.Name) {
+				bs.addRef(RefUnresolved, id.Name, id.Pos())
+				bs.addRef(RefUnresolved, id.Name+"."+x.Sel.Name, x.Sel.Pos())
+				return
+			}
+		}
+		if id, ok := x.X.(*ast.Ident); ok {
+			if typeName, local := bs.scope.lookup(id.Name); local {
+				if typeName != "" {
+					bs.addRef(RefUnresolved, typeName+"."+x.Sel.Name, x.Sel.Pos())
+				} else {
+					bs.addRef(RefSelector, x.Sel.Name, x.Sel.Pos())
+				}
+				return
+			}
+			if bs.isImportName(id.Name) {
+				return
+			}
+			// a method expression or a member of package-level value.
+			bs.addRef(RefUnresolved, id.Name, id.Pos())
+			bs.addRef(RefUnresolved, id.Name+"."+x.Sel.Name, x.Sel.Pos())
+			return
+		}
+		bs.expr(x.X)
+		bs.addRef(RefSelector, x.Sel.Name, x.Sel.Pos())
+	case *ast.CallExpr:
+		bs.callee(x.Fun)
+		bs.exprs(x.Args)
+	case *ast.FuncLit:
+		bs.push()
+		bs.defineFields(x.Type.Params, true)
+		bs.defineFields(x.Type.Results, true)
+		bs.stmt(x.Body)
+		bs.pop()
+	case *ast.CompositeLit:
+		bs.compositeLit(x, nil)
+	case *ast.KeyValueExpr:
+		bs.expr(x.Key)
+		bs.expr(x.Value)
+	case *ast.ParenExpr:
+		bs.expr(x.X)
+	case *ast.StarExpr:
+		bs.expr(x.X)
+	case *ast.UnaryExpr:
+		bs.expr(x.X)
+	case *ast.BinaryExpr:
+		bs.expr(x.X)
+		bs.expr(x.Y)
+	case *ast.IndexExpr:
+		bs.expr(x.X)
+		bs.expr(x.Index)
+	case *ast.IndexListExpr:
+		bs.expr(x.X)
+		bs.exprs(x.Indices)
+	case *ast.SliceExpr:
+		bs.expr(x.X)
+		bs.expr(x.Low)
+		bs.expr(x.High)
+		bs.expr(x.Max)
+	case *ast.TypeAssertExpr:
+		bs.expr(x.X)
+		bs.expr(x.Type)
+	case *ast.ArrayType:
+		bs.expr(x.Len)
+		bs.expr(x.Elt)
+	case *ast.MapType:
+		bs.expr(x.Key)
+		bs.expr(x.Value)
+	case *ast.ChanType:
+		bs.expr(x.Value)
+	case *ast.Ellipsis:
+		bs.expr(x.Elt)
+	case *ast.FuncType:
+		bs.fieldTypes(x.Params)
+		bs.fieldTypes(x.Results)
+	case *ast.StructType:
+		bs.fieldTypes(x.Fields)
+	case *ast.InterfaceType:
+		bs.fieldTypes(x.Methods)
+	}
+}
+
+// compositeLit scans a composite literal.  typ is the type of the literal
+// which is given by the outer literal, for a literal with an elided type
+// like elements of "[]T{{key: v}}".
+func (bs *bodyScanner) compositeLit(x *ast.CompositeLit, typ ast.Expr) {
+	if x.Type != nil {
+		bs.expr(x.Type)
+		typ = x.Type
+	}
+	if star, ok := unparen(typ).(*ast.StarExpr); ok && x.Type == nil {
+		// an elided "&T" in "[]*T{{...}}".
+		typ = star.X
+	}
+	var keyType, elemType ast.Expr
+	typeName := ""
+	switch t := unparen(typ).(type) {
+	case nil:
+	case *ast.ArrayType:
+		elemType = t.Elt
+	case *ast.MapType:
+		keyType, elemType = t.Key, t.Value
+	default:
+		typeName = bs.localTypeName(t)
+	}
+	isStruct := typ != nil && keyType == nil && elemType == nil
+	if typeName != "" && isStruct && len(x.Elts) > 0 {
+		if _, ok := x.Elts[0].(*ast.KeyValueExpr); !ok {
+			bs.addRef(RefUnresolved, typeName, x.Lbrace).Unkeyed = true
+		}
+	}
+	for _, elt := range x.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			bs.element(elt, elemType)
+			continue
+		}
+		if id, ok := kv.Key.(*ast.Ident); ok && (typ == nil || isStruct) {
+			// a field name of struct literal, or an unknown key which
+			// can't be resolved.
+			if typeName != "" {
+				bs.addRef(RefUnresolved, typeName+"."+id.Name, id.Pos())
+			}
+			bs.element(kv.Value, elemType)
+			continue
+		}
+		bs.element(kv.Key, keyType)
+		bs.element(kv.Value, elemType)
+	}
+}
+
+// element scans an element of a composite literal, whose type is typ.
+func (bs *bodyScanner) element(x ast.Expr, typ ast.Expr) {
+	if lit, ok := x.(*ast.CompositeLit); ok {
+		bs.compositeLit(lit, typ)
+		return
+	}
+	bs.expr(x)
+}
+
+func (bs *bodyScanner) fieldTypes(fl *ast.FieldList) {
+	if fl == nil {
+		return
+	}
+	for _, f := range fl.List {
+		bs.expr(f.Type)
+	}
+}
+
+// isImportName checks name is an imported package name in the file.
+func (bs *bodyScanner) isImportName(name string) bool {
+	if bs.p.file == nil {
+		return false
+	}
+	for _, imp := range bs.p.file.Imports {
+		if imp.Name == name {
+			return true
+		}
+		if imp.Name == "" && importBaseName(imp.Path) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// importBaseName guesses a package name from its import path, like
+// goimports does.
+func importBaseName(path string) string {
+	base := path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		base = path[i+1:]
+		// "example.com/bar/v2" is "bar"
+		if isMajorVersion(base) {
+			path = path[:i]
+			base = path[strings.LastIndex(path, "/")+1:]
+		}
+	}
+	// "gopkg.in/yaml.v3" is "yaml", "github.com/foo/go-bar" is "bar"
+	if i := strings.Index(base, "."); i >= 0 {
+		base = base[:i]
+	}
+	if i := strings.LastIndex(base, "-"); i >= 0 {
+		base = base[i+1:]
+	}
+	return base
+}
+
+// isMajorVersion checks s is a major version suffix of module paths, like
+// "v2".
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
+
+// methodExprType returns a type name of a method expression with pointer
+// receiver, like "(*T).M".
+func methodExprType(x ast.Expr) (*ast.Ident, bool) {
+	p, ok := x.(*ast.ParenExpr)
+	if !ok {
+		return nil, false
+	}
+	star, ok := unparen(p.X).(*ast.StarExpr)
+	if !ok {
+		return nil, false
+	}
+	id, ok := unparen(star.X).(*ast.Ident)
+	return id, ok
+}
+
+func unparen(x ast.Expr) ast.Expr {
+	for {
+		p, ok := x.(*ast.ParenExpr)
+		if !ok {
+			return x
+		}
+		x = p.X
+	}
+}
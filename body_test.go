@@ -0,0 +1,247 @@
+package srcdom_test
+
+import (
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+const bodySource = `package foo
+
+import (
+	"fmt"
+	str "strings"
+)
+
+func New(name string) *Client {
+	c := &Client{Name: name}
+	c.init()
+	return c
+}
+
+func (c *Client) init() {
+	c.count = defaultCount
+	fmt.Println(str.ToUpper(c.Name))
+}
+
+func (c *Client) Do() error {
+	helper := func(n int) int { return twice(n) }
+	helper(c.count)
+	var other Client
+	other.init()
+	return nil
+}
+
+func twice(n int) int { return n * 2 }
+
+func unused() {
+	f := (*Client).Do
+	_ = f
+	len := 1
+	_ = len
+}
+`
+
+const bodySource2 = `package foo
+
+type Client struct {
+	Name  string
+	count int
+}
+
+const defaultCount = 3
+`
+
+func readBodies(t *testing.T) *srcdom.Package {
+	t.Helper()
+	fset := token.NewFileSet()
+	p := &srcdom.Parser{Fset: fset, ScanBodies: true}
+	for i, src := range []string{bodySource, bodySource2} {
+		file, err := parser.ParseFile(fset, "foo"+strconv.Itoa(i)+".go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.ScanFile(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Package.ResolveRefs()
+	return p.Package
+}
+
+type ref struct {
+	Kind srcdom.RefKind
+	Name string
+	Call bool
+}
+
+func toRefs(refs []*srcdom.Ref) []ref {
+	var got []ref
+	for _, r := range refs {
+		if r.Kind == srcdom.RefUnresolved {
+			continue
+		}
+		got = append(got, ref{r.Kind, r.Name, r.Call})
+	}
+	return got
+}
+
+func TestFuncRefs(t *testing.T) {
+	pkg := readBodies(t)
+	for _, tc := range []struct {
+		name string
+		want []ref
+	}{
+		{"New", []ref{
+			{srcdom.RefType, "Client", false},
+			{srcdom.RefType, "Client", false},
+			{srcdom.RefField, "Client.Name", false},
+			{srcdom.RefMethod, "Client.init", true},
+		}},
+		{"Client.init", []ref{
+			{srcdom.RefValue, "defaultCount", false},
+			{srcdom.RefField, "Client.count", false},
+			{srcdom.RefField, "Client.Name", false},
+		}},
+		{"Client.Do", []ref{
+			{srcdom.RefFunc, "twice", true},
+			{srcdom.RefField, "Client.count", false},
+			{srcdom.RefType, "Client", false},
+			{srcdom.RefMethod, "Client.init", true},
+		}},
+		{"unused", []ref{
+			{srcdom.RefType, "Client", false},
+			{srcdom.RefMethod, "Client.Do", false},
+		}},
+	} {
+		fn := findFunc(t, pkg, tc.name)
+		if d := cmp.Diff(tc.want, toRefs(fn.Refs)); d != "" {
+			t.Errorf("refs of %s mismatch: -want +got\n%s", tc.name, d)
+		}
+	}
+}
+
+func findFunc(t *testing.T, pkg *srcdom.Package, name string) *srcdom.Func {
+	t.Helper()
+	if typeName, method, ok := strings.Cut(name, "."); ok {
+		typ, ok := pkg.Type(typeName)
+		if !ok {
+			t.Fatalf("type %s not found", typeName)
+		}
+		fn, ok := typ.Method(method)
+		if !ok {
+			t.Fatalf("method %s not found", name)
+		}
+		return fn
+	}
+	fn, ok := pkg.Func(name)
+	if !ok {
+		t.Fatalf("func %s not found", name)
+	}
+	return fn
+}
+
+func TestCallersOf(t *testing.T) {
+	pkg := readBodies(t)
+	for _, tc := range []struct {
+		name string
+		want []string
+	}{
+		{"Client.init", []string{"New", "Do"}},
+		{"twice", []string{"Do"}},
+		{"New", nil},
+		{"Client.Do", nil},
+	} {
+		var got []string
+		for _, fn := range pkg.CallersOf(tc.name) {
+			got = append(got, fn.Name)
+		}
+		if d := cmp.Diff(tc.want, got); d != "" {
+			t.Errorf("callers of %s mismatch: -want +got\n%s", tc.name, d)
+		}
+	}
+}
+
+func TestScanBodiesDisabled(t *testing.T) {
+	pkg, err := srcdom.ReadSource("foo.go", []byte(bodySource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn, _ := pkg.Func("New")
+	if fn.Refs != nil {
+		t.Errorf("refs should be nil without ScanBodies: %+v", fn.Refs)
+	}
+}
+
+func TestFuncRefsImportName(t *testing.T) {
+	src := `package foo
+
+import (
+	"example.com/bar/v2"
+	"github.com/foo/go-baz"
+	"gopkg.in/yaml.v3"
+)
+
+func Run() {
+	bar.Do()
+	baz.Do()
+	yaml.Marshal(1)
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &srcdom.Parser{Fset: fset, ScanBodies: true}
+	if err := p.ScanFile(file); err != nil {
+		t.Fatal(err)
+	}
+	fn, _ := p.Package.Func("Run")
+	for _, r := range fn.Refs {
+		t.Errorf("package names should not be referred: %s", r.Name)
+	}
+}
+
+func TestFuncRefsElidedLiteral(t *testing.T) {
+	src := `package foo
+
+type T struct {
+	key int
+}
+
+const key = 1
+
+func Run() {
+	_ = []T{{key: 1}}
+	_ = map[string]*T{"a": {key: 2}}
+	_ = [][]T{{{key: 3}}}
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &srcdom.Parser{Fset: fset, ScanBodies: true}
+	if err := p.ScanFile(file); err != nil {
+		t.Fatal(err)
+	}
+	p.Package.ResolveRefs()
+	fn, _ := p.Package.Func("Run")
+	if d := cmp.Diff([]ref{
+		{srcdom.RefType, "T", false},
+		{srcdom.RefField, "T.key", false},
+		{srcdom.RefType, "T", false},
+		{srcdom.RefField, "T.key", false},
+		{srcdom.RefType, "T", false},
+		{srcdom.RefField, "T.key", false},
+	}, toRefs(fn.Refs)); d != "" {
+		t.Errorf("refs of Run mismatch: -want +got\n%s", d)
+	}
+}
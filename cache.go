@@ -0,0 +1,194 @@
+package srcdom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// cacheVersion should be updated when the format of cached Package is
+// changed.
+const cacheVersion = "srcdom-cache-17"
+
+// packageCache stores serialized packages in a directory, which are keyed
+// by hashes of source contents.
+type packageCache struct {
+	dir string
+}
+
+// cacheKey calculates a key for a package which read from src.  The key
+// depends on contents of all ".go" files in the directory, the module which
+// contains the directory, and configurations which affect to result.
+func (c *Config) cacheKey(src dirSource, testPackage bool, tags map[string]bool) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%t\x00%t\x00%t\x00%t\x00", cacheVersion, src, testPackage, c.tolerant(), c != nil && c.ScanBodies, c.skipGenerated())
+	for _, tag := range sortedTags(tags) {
+		fmt.Fprintf(h, "tag:%s\x00", tag)
+	}
+	// ImportPath and ModuleGoVersion of the package depend on go.mod.
+	if m, rel, err := findModule(src); err != nil {
+		fmt.Fprintf(h, "module-error:%s\x00", err)
+	} else if m != nil {
+		fmt.Fprintf(h, "module:%s\x00%s\x00", m.importPath(rel), m.GoVersion)
+	}
+	entries, err := fs.ReadDir(src.fsys, src.dir)
+	if err != nil {
+		return "", err
+	}
+	for _, d := range entries {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".go") {
+			continue
+		}
+		b, err := src.readFile(d.Name())
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "file:%s\x00%d\x00", d.Name(), len(b))
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedTags(tags map[string]bool) []string {
+	names := make([]string, 0, len(tags))
+	for k, v := range tags {
+		if v {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (pc *packageCache) path(key string) string {
+	return filepath.Join(pc.dir, key[:2], key+".json")
+}
+
+// load loads a package from the cache.
+func (pc *packageCache) load(key string) (*Package, bool) {
+	f, err := os.Open(pc.path(key))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	pkg, err := decodePackage(f)
+	if err != nil {
+		return nil, false
+	}
+	return pkg, true
+}
+
+// store stores a package into the cache.
+func (pc *packageCache) store(key string, pkg *Package) error {
+	name := pc.path(key)
+	err := os.MkdirAll(filepath.Dir(name), 0777)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	err = encodePackage(f, pkg)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// cachedPackage is a serialized form of Package, which includes
+// unexported fields.
+type cachedPackage struct {
+	Package *Package
+	Files   []*File
+}
+
+func encodePackage(w io.Writer, pkg *Package) error {
+	return json.NewEncoder(w).Encode(&cachedPackage{
+		Package: pkg,
+		Files:   pkg.files,
+	})
+}
+
+func decodePackage(r io.Reader) (*Package, error) {
+	var c cachedPackage
+	err := json.NewDecoder(r).Decode(&c)
+	if err != nil {
+		return nil, err
+	}
+	if c.Package == nil {
+		return nil, errors.New("no packages in cache")
+	}
+	pkg := c.Package
+	pkg.files = c.Files
+	pkg.reindex()
+	return pkg, nil
+}
+
+// reindex rebuilds all indexes in the package.
+func (p *Package) reindex() {
+	values, funcs, types, files := p.Values, p.Funcs, p.Types, p.files
+	p.Values, p.valIdx = nil, nil
+	p.Funcs, p.funIdx = nil, nil
+	p.Types, p.typIdx = nil, nil
+	p.files, p.fileIdx = nil, nil
+	for _, v := range values {
+		p.putValue(v)
+	}
+	for _, fn := range funcs {
+		p.putFunc(fn)
+	}
+	for _, typ := range types {
+		typ.reindex()
+		p.putType(typ)
+	}
+	for _, f := range files {
+		p.putFile(f)
+	}
+}
+
+// reindex rebuilds all indexes in the type.
+func (typ *Type) reindex() {
+	embeds, fields, methods := typ.Embeds, typ.Fields, typ.Methods
+	typ.Embeds, typ.embedIdx = nil, nil
+	typ.Fields, typ.fieldIdx = nil, nil
+	typ.Methods, typ.methodIdx = nil, nil
+	for _, name := range embeds {
+		typ.putEmbed(name)
+	}
+	for _, f := range fields {
+		if f.Tag != nil {
+			f.Tag.reindex()
+		}
+		typ.putField(f)
+	}
+	for _, f := range typ.EmbedFields {
+		if f.Tag != nil {
+			f.Tag.reindex()
+		}
+	}
+	for _, fn := range methods {
+		typ.putMethod(fn)
+	}
+}
+
+// reindex rebuilds the index of tag values.
+func (tag *Tag) reindex() {
+	values := tag.Values
+	tag.Values, tag.valueIdx = nil, nil
+	for _, v := range values {
+		tag.putTagValue(v)
+	}
+}
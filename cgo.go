@@ -0,0 +1,181 @@
+package srcdom
+
+import (
+	"go/ast"
+	"go/build"
+	"go/token"
+	"os"
+	"strings"
+)
+
+// CgoDirective is a "#cgo" line in the preamble of a cgo file, like
+// "#cgo linux LDFLAGS: -lm".
+type CgoDirective struct {
+	// Constraint is a build constraint of the directive, like "linux" or
+	// "linux,amd64 darwin".  It is empty when not specified.
+	Constraint string
+
+	// Name is a name of the variable, like "CFLAGS", "LDFLAGS" or
+	// "pkg-config".
+	Name string
+
+	Args []string
+
+	Pos token.Position
+}
+
+// UsesCgo checks some files in the package import "C".
+func (p *Package) UsesCgo() bool {
+	for _, f := range p.files {
+		if f.Cgo {
+			return true
+		}
+	}
+	return false
+}
+
+// CgoDirectives returns "#cgo" directives in all files of the package.
+func (p *Package) CgoDirectives() []*CgoDirective {
+	var list []*CgoDirective
+	for _, f := range p.files {
+		list = append(list, f.CgoDirectives...)
+	}
+	return list
+}
+
+// cgoEnabled checks cgo is enabled.  CGO_ENABLED environment variable is
+// respected, otherwise the default of the build context is used.
+func cgoEnabled() bool {
+	switch os.Getenv("CGO_ENABLED") {
+	case "1":
+		return true
+	case "0":
+		return false
+	default:
+		return build.Default.CgoEnabled
+	}
+}
+
+// cgoImport returns an import spec of "C" in the file, and its doc comment
+// which is the cgo preamble.
+func cgoImport(file *ast.File) (*ast.ImportSpec, *ast.CommentGroup) {
+	for _, decl := range file.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || d.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range d.Specs {
+			s, ok := spec.(*ast.ImportSpec)
+			if !ok || s.Path.Value != `"C"` {
+				continue
+			}
+			doc := s.Doc
+			if doc == nil && !d.Lparen.IsValid() {
+				doc = d.Doc
+			}
+			return s, doc
+		}
+	}
+	return nil, nil
+}
+
+// scanCgo marks the file as a cgo file, and extracts "#cgo" directives in
+// the preamble.
+func (p *Parser) scanCgo(file *ast.File) {
+	spec, doc := cgoImport(file)
+	if spec == nil {
+		return
+	}
+	p.file.Cgo = true
+	if doc == nil {
+		return
+	}
+	for _, c := range doc.List {
+		pos := p.position(c.Pos())
+		var lines []string
+		if s, ok := strings.CutPrefix(c.Text, "//"); ok {
+			lines = []string{s}
+		} else {
+			lines = strings.Split(strings.TrimSuffix(strings.TrimPrefix(c.Text, "/*"), "*/"), "\n")
+		}
+		for i, line := range lines {
+			d, ok := parseCgoDirective(line)
+			if !ok {
+				continue
+			}
+			d.Pos = pos
+			if pos.IsValid() {
+				d.Pos.Line += i
+				if i > 0 {
+					d.Pos.Column = 1
+				}
+			}
+			p.file.CgoDirectives = append(p.file.CgoDirectives, d)
+		}
+	}
+}
+
+// parseCgoDirective parses a line in the preamble, like the cgo command
+// does.
+func parseCgoDirective(line string) (*CgoDirective, bool) {
+	line = strings.TrimSpace(line)
+	s, ok := strings.CutPrefix(line, "#cgo")
+	if !ok || s == "" || (s[0] != ' ' && s[0] != '\t') {
+		return nil, false
+	}
+	head, rest, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, false
+	}
+	words := strings.Fields(head)
+	if len(words) == 0 {
+		return nil, false
+	}
+	return &CgoDirective{
+		Constraint: strings.Join(words[:len(words)-1], " "),
+		Name:       words[len(words)-1],
+		Args:       splitCgoArgs(rest),
+	}, true
+}
+
+// splitCgoArgs splits arguments of a "#cgo" directive.  Arguments can be
+// quoted with single or double quotes, and backslash escapes a character.
+func splitCgoArgs(s string) []string {
+	var args []string
+	b := &strings.Builder{}
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+			b.WriteRune(r)
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				b.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+				inArg = false
+			}
+		default:
+			b.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, b.String())
+	}
+	return args
+}
@@ -0,0 +1,67 @@
+package srcdom_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/koron-go/srcdom"
+)
+
+func TestCgoEnabled(t *testing.T) {
+	t.Setenv("CGO_ENABLED", "1")
+	pkg, err := srcdom.ReadDir("_testdata/cgo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pkg.UsesCgo() {
+		t.Error("package should use cgo")
+	}
+	want := []string{
+		filepath.Join("_testdata", "cgo", "cgo.go"),
+		filepath.Join("_testdata", "cgo", "pure.go"),
+	}
+	if d := cmp.Diff(want, pkg.FileNames()); d != "" {
+		t.Errorf("unmatch files: -want +got\n%s", d)
+	}
+	f, _ := pkg.File(want[0])
+	if !f.Cgo {
+		t.Error("cgo.go should be marked as cgo")
+	}
+
+	wantDirectives := []*srcdom.CgoDirective{
+		{Name: "CFLAGS", Args: []string{"-I${SRCDIR}/include", "-DNAME=foo bar"}},
+		{Constraint: "linux,amd64 darwin", Name: "LDFLAGS", Args: []string{"-lm"}},
+		{Name: "pkg-config", Args: []string{"libpng"}},
+	}
+	got := pkg.CgoDirectives()
+	if d := cmp.Diff(wantDirectives, got, cmpopts.IgnoreFields(srcdom.CgoDirective{}, "Pos")); d != "" {
+		t.Errorf("unmatch cgo directives: -want +got\n%s", d)
+	}
+	var lines []int
+	for _, d := range got {
+		lines = append(lines, d.Pos.Line)
+	}
+	if d := cmp.Diff([]int{4, 5, 8}, lines); d != "" {
+		t.Errorf("unmatch lines of cgo directives: -want +got\n%s", d)
+	}
+}
+
+func TestCgoDisabled(t *testing.T) {
+	t.Setenv("CGO_ENABLED", "0")
+	pkg, err := srcdom.ReadDir("_testdata/cgo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.UsesCgo() {
+		t.Error("package should not use cgo")
+	}
+	want := []string{
+		filepath.Join("_testdata", "cgo", "nocgo.go"),
+		filepath.Join("_testdata", "cgo", "pure.go"),
+	}
+	if d := cmp.Diff(want, pkg.FileNames()); d != "" {
+		t.Errorf("unmatch files: -want +got\n%s", d)
+	}
+}
@@ -0,0 +1,271 @@
+package srcdom
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is a kind of Change.
+type ChangeKind int
+
+// Kinds of Change.
+const (
+	PackageAdded ChangeKind = iota + 1
+	PackageRemoved
+	TypeAdded
+	TypeRemoved
+	TypeChanged
+	EmbedAdded
+	EmbedRemoved
+	FieldAdded
+	FieldRemoved
+	FieldChanged
+	MethodAdded
+	MethodRemoved
+	MethodChanged
+	FuncAdded
+	FuncRemoved
+	FuncChanged
+	ValueAdded
+	ValueRemoved
+	ValueChanged
+)
+
+var changeKindNames = map[ChangeKind]string{
+	PackageAdded:   "package added",
+	PackageRemoved: "package removed",
+	TypeAdded:      "type added",
+	TypeRemoved:    "type removed",
+	TypeChanged:    "type changed",
+	EmbedAdded:     "embed added",
+	EmbedRemoved:   "embed removed",
+	FieldAdded:     "field added",
+	FieldRemoved:   "field removed",
+	FieldChanged:   "field changed",
+	MethodAdded:    "method added",
+	MethodRemoved:  "method removed",
+	MethodChanged:  "method changed",
+	FuncAdded:      "func added",
+	FuncRemoved:    "func removed",
+	FuncChanged:    "func changed",
+	ValueAdded:     "value added",
+	ValueRemoved:   "value removed",
+	ValueChanged:   "value changed",
+}
+
+func (k ChangeKind) String() string {
+	if s, ok := changeKindNames[k]; ok {
+		return s
+	}
+	return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Change represents a change of a declaration between two snapshots of a
+// package.
+type Change struct {
+	Kind ChangeKind
+
+	// Name is a name of the changed declaration.  Fields and methods are
+	// named in the form of "{Type}.{Name}".
+	Name string
+
+	// Old and New are descriptions of the declaration before and after
+	// the change.  Those are empty for added or removed declarations.
+	Old string
+	New string
+}
+
+// String returns a string representation of the change.
+func (c *Change) String() string {
+	s := c.Kind.String() + ": " + c.Name
+	if c.Old != "" || c.New != "" {
+		s += " (" + c.Old + " -> " + c.New + ")"
+	}
+	return s
+}
+
+// signature returns a signature of the function without its name, like
+// "(string, int) error".
+func (fn *Func) signature() string {
+	b := &strings.Builder{}
+	b.WriteString("(" + typesString(fn.Params) + ")")
+	fn.writeResults(b)
+	return b.String()
+}
+
+// pointerRecv checks the method has a pointer receiver.
+func (fn *Func) pointerRecv() bool {
+	return strings.HasPrefix(fn.Recv, "*")
+}
+
+// kind returns a kind of the type: "struct", "interface" or "other".
+func (typ *Type) kind() string {
+	switch {
+	case typ.IsStruct:
+		return "struct"
+	case typ.IsInterface:
+		return "interface"
+	default:
+		return "other"
+	}
+}
+
+// describe returns a description of the type without fields and methods,
+// like "struct", "int" or "= other.Type".
+func (typ *Type) describe() string {
+	if typ.Expr == "" {
+		return typ.kind()
+	}
+	if typ.Alias {
+		return "= " + typ.Expr
+	}
+	return typ.Expr
+}
+
+func (f *Field) describe() string {
+	if f.Tag == nil || f.Tag.Raw == "" {
+		return f.Type
+	}
+	return f.Type + " " + strconv.Quote(f.Tag.Raw)
+}
+
+func (v *Value) describe() string {
+	b := &strings.Builder{}
+	if v.IsConst {
+		b.WriteString("const")
+	} else {
+		b.WriteString("var")
+	}
+	if v.TypeExpr != "" {
+		b.WriteString(" " + v.TypeExpr)
+	}
+	if v.Literal != nil {
+		b.WriteString(" = " + v.Literal.Value)
+	}
+	return b.String()
+}
+
+// comparePackages compares two snapshots of a package, and returns changes
+// of declarations.  Either of old or new can be nil.
+func comparePackages(old, new *Package) []*Change {
+	switch {
+	case old == nil && new == nil:
+		return nil
+	case old == nil:
+		return []*Change{{Kind: PackageAdded, Name: new.Name}}
+	case new == nil:
+		return []*Change{{Kind: PackageRemoved, Name: old.Name}}
+	}
+	var changes []*Change
+	add := func(kind ChangeKind, name, o, n string) {
+		changes = append(changes, &Change{Kind: kind, Name: name, Old: o, New: n})
+	}
+
+	for _, name := range unionNames(old.valIdx, new.valIdx) {
+		ov, ook := old.Value(name)
+		nv, nok := new.Value(name)
+		switch {
+		case !ook:
+			add(ValueAdded, name, "", "")
+		case !nok:
+			add(ValueRemoved, name, "", "")
+		default:
+			if o, n := ov.describe(), nv.describe(); o != n {
+				add(ValueChanged, name, o, n)
+			}
+		}
+	}
+
+	for _, name := range unionNames(old.funIdx, new.funIdx) {
+		of, ook := old.Func(name)
+		nf, nok := new.Func(name)
+		switch {
+		case !ook:
+			add(FuncAdded, name, "", "")
+		case !nok:
+			add(FuncRemoved, name, "", "")
+		default:
+			if o, n := of.signature(), nf.signature(); o != n {
+				add(FuncChanged, name, o, n)
+			}
+		}
+	}
+
+	for _, name := range unionNames(old.typIdx, new.typIdx) {
+		ot, ook := old.Type(name)
+		nt, nok := new.Type(name)
+		switch {
+		case !ook:
+			add(TypeAdded, name, "", "")
+		case !nok:
+			add(TypeRemoved, name, "", "")
+		default:
+			changes = append(changes, compareTypes(ot, nt)...)
+		}
+	}
+	return changes
+}
+
+func compareTypes(old, new *Type) []*Change {
+	var changes []*Change
+	add := func(kind ChangeKind, name, o, n string) {
+		changes = append(changes, &Change{Kind: kind, Name: old.Name + "." + name, Old: o, New: n})
+	}
+	if o, n := old.describe(), new.describe(); o != n {
+		changes = append(changes, &Change{Kind: TypeChanged, Name: old.Name, Old: o, New: n})
+	}
+	for _, name := range unionNames(old.embedIdx, new.embedIdx) {
+		switch {
+		case !old.Embed(name):
+			add(EmbedAdded, name, "", "")
+		case !new.Embed(name):
+			add(EmbedRemoved, name, "", "")
+		}
+	}
+	for _, name := range unionNames(old.fieldIdx, new.fieldIdx) {
+		of, ook := old.Field(name)
+		nf, nok := new.Field(name)
+		switch {
+		case !ook:
+			add(FieldAdded, name, "", "")
+		case !nok:
+			add(FieldRemoved, name, "", "")
+		default:
+			if o, n := of.describe(), nf.describe(); o != n {
+				add(FieldChanged, name, o, n)
+			}
+		}
+	}
+	for _, name := range unionNames(old.methodIdx, new.methodIdx) {
+		om, ook := old.Method(name)
+		nm, nok := new.Method(name)
+		switch {
+		case !ook:
+			add(MethodAdded, name, "", "")
+		case !nok:
+			add(MethodRemoved, name, "", "")
+		default:
+			o, n := om.signature(), nm.signature()
+			if om.pointerRecv() != nm.pointerRecv() {
+				// method sets of value types depend on receivers.
+				o, n = "("+om.Recv+") "+o, "("+nm.Recv+") "+n
+			}
+			if o != n {
+				add(MethodChanged, name, o, n)
+			}
+		}
+	}
+	return changes
+}
+
+// unionNames returns sorted names which included in a or b.
+func unionNames(a, b map[string]int) []string {
+	m := make(map[string]int, len(a)+len(b))
+	for k := range a {
+		m[k] = 0
+	}
+	for k := range b {
+		m[k] = 0
+	}
+	return sortedNames(m)
+}
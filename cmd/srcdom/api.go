@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/koron-go/srcdom"
+)
+
+func init() {
+	commands["api"] = &command{
+		summary: "list exported API of packages",
+		run:     runAPI,
+	}
+}
+
+func runAPI(args []string) error {
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: srcdom api {DIR...}\n\n")
+		fs.PrintDefaults()
+	}
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	for _, dir := range fs.Args() {
+		pkg, err := srcdom.ReadDir(dir, false)
+		if err != nil {
+			return err
+		}
+		for _, line := range pkg.APISurface() {
+			fmt.Fprintln(os.Stdout, line)
+		}
+	}
+	return nil
+}
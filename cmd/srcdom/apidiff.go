@@ -0,0 +1,125 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/koron-go/srcdom"
+)
+
+func init() {
+	commands["apidiff"] = &command{
+		summary: "report changes of exported API",
+		run:     runAPIDiff,
+	}
+}
+
+func runAPIDiff(args []string) error {
+	fs := flag.NewFlagSet("apidiff", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: srcdom apidiff [-git REF] {OLD_DIR} {NEW_DIR}\n       srcdom apidiff -git REF {DIR}\n\nIt exits with status 1 when incompatible changes are found.\n\n")
+		fs.PrintDefaults()
+	}
+	gitRef := fs.String("git", "", "read the old package from the git ref")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var oldPkg, newPkg *srcdom.Package
+	switch {
+	case *gitRef != "" && fs.NArg() == 1:
+		dir := fs.Arg(0)
+		oldPkg, err = readGitDir(*gitRef, dir)
+		if err != nil {
+			return err
+		}
+		newPkg, err = srcdom.ReadDir(dir, false)
+		if err != nil {
+			return err
+		}
+	case *gitRef == "" && fs.NArg() == 2:
+		oldPkg, err = srcdom.ReadDir(fs.Arg(0), false)
+		if err != nil {
+			return err
+		}
+		newPkg, err = srcdom.ReadDir(fs.Arg(1), false)
+		if err != nil {
+			return err
+		}
+	default:
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	d := srcdom.Diff(oldPkg, newPkg)
+	err = d.WriteReport(os.Stdout)
+	if err != nil {
+		return err
+	}
+	if d.Breaking() {
+		return errFailed
+	}
+	return nil
+}
+
+// readGitDir reads a package in the directory at the git ref.
+func readGitDir(ref, dir string) (*srcdom.Package, error) {
+	prefix, err := gitOutput(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	treeish := ref + ":" + strings.TrimSpace(string(prefix))
+	archive, err := gitOutput(dir, "archive", "--format=tar", treeish)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "srcdom-apidiff-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	r := tar.NewReader(bytes.NewReader(archive))
+	for {
+		h, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// read only files in the directory.
+		if h.Typeflag != tar.TypeReg || strings.Contains(h.Name, "/") || path.Ext(h.Name) != ".go" {
+			continue
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(filepath.Join(tmp, h.Name), b, 0666)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return srcdom.ReadDir(tmp, false)
+}
+
+func gitOutput(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return out, nil
+}
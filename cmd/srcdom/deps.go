@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/koron-go/srcdom"
+)
+
+func init() {
+	commands["deps"] = &command{
+		summary: "check import cycles and layering rules",
+		run:     runDeps,
+	}
+}
+
+func runDeps(args []string) error {
+	fs := flag.NewFlagSet("deps", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: srcdom deps [-rules FILE] [-graph] {DIR}\n\nIt exits with 1 when import cycles or violations of rules are found.\n\n")
+		fs.PrintDefaults()
+	}
+	rulesFile := fs.String("rules", "", "file of layering rules, like \"deny ./domain/... -> ./infra/...\"")
+	graph := fs.Bool("graph", false, "print imports between packages in the tree")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	var rules []*srcdom.LayerRule
+	if *rulesFile != "" {
+		f, err := os.Open(*rulesFile)
+		if err != nil {
+			return err
+		}
+		rules, err = srcdom.ParseLayerRules(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", *rulesFile, err)
+		}
+	}
+	l := &srcdom.Loader{Config: &srcdom.Config{}}
+	prog, err := l.LoadTree(context.Background(), fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	g := prog.ImportGraph()
+	if *graph {
+		for _, e := range g.Edges {
+			if _, ok := prog.PackageByImportPath(e.To); !ok {
+				continue
+			}
+			suffix := ""
+			if e.Constrained {
+				suffix = " (constrained)"
+			}
+			fmt.Fprintf(os.Stdout, "%s -> %s%s\n", e.From, e.To, suffix)
+		}
+	}
+	found := false
+	for _, c := range g.Cycles() {
+		suffix := ""
+		if c.Constrained {
+			suffix = " (with other build tags)"
+		}
+		fmt.Fprintf(os.Stdout, "import cycle: %s%s\n", c, suffix)
+		found = true
+	}
+	violations, err := g.CheckLayers(rules)
+	if err != nil {
+		return err
+	}
+	for _, v := range violations {
+		fmt.Fprintf(os.Stdout, "%s\n", v)
+		found = true
+	}
+	if found {
+		return errFailed
+	}
+	return nil
+}
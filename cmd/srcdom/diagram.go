@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/koron-go/srcdom"
+)
+
+func init() {
+	commands["diagram"] = &command{
+		summary: "draw a class diagram of types in DOT or Mermaid",
+		run:     runDiagram,
+	}
+}
+
+func runDiagram(args []string) error {
+	fs := flag.NewFlagSet("diagram", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: srcdom diagram [OPTIONS] {DIR}\n\n")
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "dot", "output format: dot or mermaid")
+	tree := fs.Bool("tree", false, "include all packages under DIR")
+	exported := fs.Bool("exported", false, "include only exported types and members")
+	roots := fs.String("roots", "", "comma separated types which the diagram starts from")
+	depth := fs.Int("depth", 0, "limit relations from roots, no limit with 0")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	opts := &srcdom.DiagramOptions{ExportedOnly: *exported, Depth: *depth}
+	if *roots != "" {
+		opts.Roots = strings.Split(*roots, ",")
+	}
+	var d *srcdom.Diagram
+	if *tree {
+		l := &srcdom.Loader{Config: &srcdom.Config{}}
+		prog, err := l.LoadTree(context.Background(), fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		d = prog.Diagram(opts)
+	} else {
+		pkg, err := srcdom.ReadDir(fs.Arg(0), false)
+		if err != nil {
+			return err
+		}
+		d = pkg.Diagram(opts)
+	}
+	switch *format {
+	case "dot":
+		return d.WriteDOT(os.Stdout)
+	case "mermaid":
+		return d.WriteMermaid(os.Stdout)
+	default:
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+}
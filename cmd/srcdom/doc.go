@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/koron-go/srcdom"
+)
+
+func init() {
+	commands["doc"] = &command{
+		summary: "generate API reference in Markdown or HTML",
+		run:     runDoc,
+	}
+}
+
+func runDoc(args []string) error {
+	fs := flag.NewFlagSet("doc", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: srcdom doc [-format FORMAT] [-o FILE] {DIR}\n\n")
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "markdown", "output format: markdown or html")
+	output := fs.String("o", "", "output file, default is stdout")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	var write func(d *srcdom.PackageDoc, w io.Writer) error
+	switch *format {
+	case "markdown", "md":
+		write = (*srcdom.PackageDoc).WriteMarkdown
+	case "html":
+		write = (*srcdom.PackageDoc).WriteHTML
+	default:
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+	set, err := srcdom.ReadPackageSet(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		return write(set.Doc(), os.Stdout)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(set.Doc(), f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,57 @@
+// Package main provides the srcdom command, which offers tools built on
+// srcdom.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]*command{}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: srcdom <command> [arguments]\n\nCommands:\n")
+	names := make([]string, 0, len(commands))
+	for n := range commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", n, commands[n].summary)
+	}
+}
+
+// errFailed is returned by commands which have reported failures by
+// themselves, to exit with non-zero status.
+var errFailed = errors.New("failed")
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "srcdom: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	err := cmd.run(os.Args[2:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		if !errors.Is(err, errFailed) {
+			fmt.Fprintf(os.Stderr, "srcdom %s: %s\n", os.Args[1], err)
+		}
+		os.Exit(1)
+	}
+}
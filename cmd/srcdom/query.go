@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/koron-go/srcdom"
+)
+
+func init() {
+	commands["query"] = &command{
+		summary: "select declarations with a query",
+		run:     runQuery,
+	}
+}
+
+func runQuery(args []string) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: srcdom query [-dir DIR] {QUERY}\n\nExample: srcdom query 'funcs where public and returns error'\n\n")
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", ".", "directory of the package")
+	test := fs.Bool("test", false, "read the test package")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	pkg, err := srcdom.ReadDir(*dir, *test)
+	if err != nil {
+		return err
+	}
+	matches, err := pkg.Query(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		fmt.Fprintf(os.Stdout, "%s: %s\n", m.Pos, m.Name)
+	}
+	return nil
+}
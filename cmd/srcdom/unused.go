@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/koron-go/srcdom"
+)
+
+func init() {
+	commands["unused"] = &command{
+		summary: "report unused unexported declarations",
+		run:     runUnused,
+	}
+}
+
+func runUnused(args []string) error {
+	fs := flag.NewFlagSet("unused", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: srcdom unused {DIR...}\n\nIt exits with 1 when unused declarations are found.\n\n")
+		fs.PrintDefaults()
+	}
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	cfg := &srcdom.Config{ScanBodies: true}
+	found := false
+	for _, dir := range fs.Args() {
+		pkg, err := cfg.ReadDir(dir, false)
+		if err != nil {
+			return err
+		}
+		for _, m := range pkg.Unused() {
+			fmt.Fprintf(os.Stdout, "%s: %s %s is unused\n", m.Pos, nodeKind(m.Node), m.Name)
+			found = true
+		}
+	}
+	if found {
+		return errFailed
+	}
+	return nil
+}
+
+func nodeKind(n srcdom.Node) string {
+	switch n := n.(type) {
+	case *srcdom.Type:
+		return "type"
+	case *srcdom.Func:
+		return "func"
+	case *srcdom.Field:
+		return "field"
+	case *srcdom.Value:
+		if n.IsConst {
+			return "const"
+		}
+		return "var"
+	default:
+		return "declaration"
+	}
+}
@@ -0,0 +1,434 @@
+package srcdom
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ImportGraph is a graph of imports between packages in a Program.  Nodes
+// are import paths of packages.
+type ImportGraph struct {
+	// Packages are import paths of packages in the program, sorted.
+	// Packages without import paths are not included.
+	Packages []string
+
+	// Edges are imports from packages in the program, sorted by From and
+	// To.  Imports of packages out of the program are included too.
+	Edges []*ImportEdge
+
+	module  *Module
+	edgeIdx map[[2]string]int
+}
+
+// ImportEdge is an edge of ImportGraph.
+type ImportEdge struct {
+	From string
+	To   string
+
+	// Imports are import declarations which make the edge.
+	Imports []*Import
+
+	// Constrained is true when the edge is made only by files which
+	// excluded by build constraints.
+	Constrained bool
+
+	constrained map[*Import]bool
+}
+
+// ImportGraph builds an import graph of packages in the program.
+func (prog *Program) ImportGraph() *ImportGraph {
+	g := &ImportGraph{module: prog.Module}
+	for _, pkg := range prog.Packages {
+		if pkg.ImportPath == "" {
+			continue
+		}
+		g.Packages = append(g.Packages, pkg.ImportPath)
+		for _, imp := range pkg.Imports {
+			g.putEdge(pkg.ImportPath, imp, false)
+		}
+		for _, imp := range pkg.ConstrainedImports {
+			g.putEdge(pkg.ImportPath, imp, true)
+		}
+	}
+	sort.Strings(g.Packages)
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	for i, e := range g.Edges {
+		g.edgeIdx[[2]string{e.From, e.To}] = i
+	}
+	return g
+}
+
+func (g *ImportGraph) putEdge(from string, imp *Import, constrained bool) {
+	if g.edgeIdx == nil {
+		g.edgeIdx = make(map[[2]string]int)
+	}
+	key := [2]string{from, imp.Path}
+	if idx, ok := g.edgeIdx[key]; ok {
+		e := g.Edges[idx]
+		e.Imports = append(e.Imports, imp)
+		e.Constrained = e.Constrained && constrained
+		e.putConstrained(imp, constrained)
+		return
+	}
+	g.edgeIdx[key] = len(g.Edges)
+	e := &ImportEdge{
+		From:        from,
+		To:          imp.Path,
+		Imports:     []*Import{imp},
+		Constrained: constrained,
+	}
+	e.putConstrained(imp, constrained)
+	g.Edges = append(g.Edges, e)
+}
+
+func (e *ImportEdge) putConstrained(imp *Import, constrained bool) {
+	if !constrained {
+		return
+	}
+	if e.constrained == nil {
+		e.constrained = make(map[*Import]bool)
+	}
+	e.constrained[imp] = true
+}
+
+// IsConstrained checks the import declaration of the edge is in a file which
+// excluded by build constraints.
+func (e *ImportEdge) IsConstrained(imp *Import) bool {
+	return e.constrained[imp]
+}
+
+// Edge gets an edge between two packages.
+func (g *ImportGraph) Edge(from, to string) (*ImportEdge, bool) {
+	idx, ok := g.edgeIdx[[2]string{from, to}]
+	if !ok {
+		return nil, false
+	}
+	return g.Edges[idx], true
+}
+
+// ImportCycle is a cycle of imports.
+type ImportCycle struct {
+	// Path is import paths in the cycle, which starts with the smallest
+	// one.  The last one imports the first one.
+	Path []string
+
+	// Constrained is true when the cycle exists only with files which
+	// excluded by build constraints.
+	Constrained bool
+}
+
+func (c *ImportCycle) String() string {
+	return strings.Join(append(c.Path, c.Path[0]), " -> ")
+}
+
+// Cycles detects import cycles between packages in the graph, including
+// cycles which exist under alternate build tags.  One cycle is reported
+// for each set of strongly connected packages.  Cycles which exist only
+// with edges under alternate build tags are reported as constrained, in
+// addition to cycles without those.
+func (g *ImportGraph) Cycles() []*ImportCycle {
+	var cycles []*ImportCycle
+	size := map[string]int{}
+	for _, comp := range g.components(false) {
+		for _, v := range comp {
+			size[v] = len(comp)
+		}
+		path := g.cyclePath(comp)
+		if path == nil {
+			continue
+		}
+		cycles = append(cycles, &ImportCycle{Path: path})
+	}
+	for _, comp := range g.components(true) {
+		// components without constrained edges are subsets of ones with
+		// those, so same sizes mean no additional packages.
+		if size[comp[0]] == len(comp) {
+			continue
+		}
+		path := g.constrainedCyclePath(comp)
+		if path == nil {
+			continue
+		}
+		cycles = append(cycles, &ImportCycle{Path: path, Constrained: true})
+	}
+	sort.SliceStable(cycles, func(i, j int) bool {
+		return cycles[i].Path[0] < cycles[j].Path[0]
+	})
+	return cycles
+}
+
+// successors returns packages in the graph which imported by the package.
+func (g *ImportGraph) successors(from string, constrained bool) []string {
+	var list []string
+	i := sort.Search(len(g.Edges), func(i int) bool { return g.Edges[i].From >= from })
+	for ; i < len(g.Edges) && g.Edges[i].From == from; i++ {
+		e := g.Edges[i]
+		if e.Constrained && !constrained {
+			continue
+		}
+		if j := sort.SearchStrings(g.Packages, e.To); j < len(g.Packages) && g.Packages[j] == e.To {
+			list = append(list, e.To)
+		}
+	}
+	return list
+}
+
+// components returns strongly connected components of packages, by
+// Tarjan's algorithm.
+func (g *ImportGraph) components(constrained bool) [][]string {
+	var (
+		index   = map[string]int{}
+		lowlink = map[string]int{}
+		onStack = map[string]bool{}
+		stack   []string
+		comps   [][]string
+	)
+	var visit func(v string)
+	visit = func(v string) {
+		index[v] = len(index)
+		lowlink[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range g.successors(v, constrained) {
+			if _, ok := index[w]; !ok {
+				visit(w)
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			} else if onStack[w] {
+				lowlink[v] = min(lowlink[v], index[w])
+			}
+		}
+		if lowlink[v] != index[v] {
+			return
+		}
+		var comp []string
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			comp = append(comp, w)
+			if w == v {
+				break
+			}
+		}
+		sort.Strings(comp)
+		comps = append(comps, comp)
+	}
+	for _, v := range g.Packages {
+		if _, ok := index[v]; !ok {
+			visit(v)
+		}
+	}
+	return comps
+}
+
+// cyclePath finds a shortest cycle from the smallest package in the
+// component, without constrained edges.  It returns nil when the component
+// has no cycles.
+func (g *ImportGraph) cyclePath(comp []string) []string {
+	start := comp[0]
+	inComp := toSet(comp)
+	var cycle []string
+	for _, w := range g.successors(start, false) {
+		if !inComp[w] {
+			continue
+		}
+		path := g.shortestPath(w, start, inComp, false)
+		if path != nil && (cycle == nil || len(path) < len(cycle)) {
+			cycle = path
+		}
+	}
+	if cycle == nil {
+		return nil
+	}
+	// the path ends with start, so move it to the head.
+	return append([]string{start}, cycle[:len(cycle)-1]...)
+}
+
+// constrainedCyclePath finds a shortest cycle in the component, which
+// contains at least one constrained edge.  It returns nil when there are
+// no such cycles.
+func (g *ImportGraph) constrainedCyclePath(comp []string) []string {
+	inComp := toSet(comp)
+	var cycle []string
+	for _, e := range g.Edges {
+		if !e.Constrained || !inComp[e.From] || !inComp[e.To] {
+			continue
+		}
+		// a path from To to From makes a cycle with the edge.
+		path := g.shortestPath(e.To, e.From, inComp, true)
+		if path != nil && (cycle == nil || len(path) < len(cycle)) {
+			cycle = path
+		}
+	}
+	if cycle == nil {
+		return nil
+	}
+	// rotate the cycle to start with the smallest package.
+	first := 0
+	for i, v := range cycle {
+		if v < cycle[first] {
+			first = i
+		}
+	}
+	return append(cycle[first:len(cycle):len(cycle)], cycle[:first]...)
+}
+
+// shortestPath finds a shortest path from a package to another package in
+// the component, by breadth first search.  It returns nil when no paths
+// are found.
+func (g *ImportGraph) shortestPath(from, to string, inComp map[string]bool, constrained bool) []string {
+	prev := map[string]string{from: from}
+	queue := []string{from}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		if v == to {
+			var path []string
+			for u := to; u != from; u = prev[u] {
+				path = append(path, u)
+			}
+			path = append(path, from)
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path
+		}
+		for _, w := range g.successors(v, constrained) {
+			if !inComp[w] {
+				continue
+			}
+			if _, ok := prev[w]; !ok {
+				prev[w] = v
+				queue = append(queue, w)
+			}
+		}
+	}
+	return nil
+}
+
+// LayerRule is a rule which denies imports from packages to packages.
+type LayerRule struct {
+	// From and To are patterns of import paths.  "..." matches any
+	// string, and "x/..." matches "x" too.  Patterns which start with "./"
+	// are relative to the module path.
+	From string
+	To   string
+
+	// Line is the line number in the rules file.
+	Line int
+}
+
+func (r *LayerRule) String() string {
+	return "deny " + r.From + " -> " + r.To
+}
+
+// ParseLayerRules parses layering rules.  Each line is a rule in the form
+// of "deny {from} -> {to}".  Empty lines and lines start with "#" are
+// ignored.  For example:
+//
+//	# domain must not import infra
+//	deny ./domain/... -> ./infra/...
+func ParseLayerRules(r io.Reader) ([]*LayerRule, error) {
+	var rules []*LayerRule
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Fields(line)
+		if len(f) != 4 || f[0] != "deny" || f[2] != "->" {
+			return nil, fmt.Errorf("line %d: invalid rule, want \"deny {from} -> {to}\": %s", n, line)
+		}
+		rules = append(rules, &LayerRule{From: f[1], To: f[3], Line: n})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// LayerViolation is an import which violates a LayerRule.
+type LayerViolation struct {
+	Rule *LayerRule
+	From string
+	To   string
+
+	// Pos is the position of the import declaration.
+	Pos token.Position
+
+	// Constrained is true when the import is in a file which excluded by
+	// build constraints.
+	Constrained bool
+}
+
+func (v *LayerViolation) String() string {
+	return fmt.Sprintf("%s: %s imports %s (%s)", v.Pos, v.From, v.To, v.Rule)
+}
+
+// CheckLayers checks imports in the graph with rules.  Each import
+// declaration which violates a rule is reported.
+func (g *ImportGraph) CheckLayers(rules []*LayerRule) ([]*LayerViolation, error) {
+	type matcher struct {
+		rule     *LayerRule
+		from, to *regexp.Regexp
+	}
+	var matchers []matcher
+	for _, r := range rules {
+		from, err := g.compilePattern(r.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := g.compilePattern(r.To)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher{rule: r, from: from, to: to})
+	}
+	var violations []*LayerViolation
+	for _, e := range g.Edges {
+		for _, m := range matchers {
+			if !m.from.MatchString(e.From) || !m.to.MatchString(e.To) {
+				continue
+			}
+			for _, imp := range e.Imports {
+				violations = append(violations, &LayerViolation{
+					Rule:        m.rule,
+					From:        e.From,
+					To:          e.To,
+					Pos:         imp.Pos,
+					Constrained: e.IsConstrained(imp),
+				})
+			}
+			break
+		}
+	}
+	return violations, nil
+}
+
+// compilePattern compiles a pattern of import paths into a regexp.
+func (g *ImportGraph) compilePattern(pattern string) (*regexp.Regexp, error) {
+	if rel, ok := strings.CutPrefix(pattern, "./"); ok {
+		if g.module == nil {
+			return nil, fmt.Errorf("relative pattern without go.mod: %s", pattern)
+		}
+		pattern = g.module.importPath(rel)
+	}
+	re := regexp.QuoteMeta(pattern)
+	if s, ok := strings.CutSuffix(re, `/\.\.\.`); ok {
+		re = s + `(/.*)?`
+	}
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	return regexp.Compile("^" + re + "$")
+}
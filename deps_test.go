@@ -0,0 +1,162 @@
+package srcdom_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func loadDepsTree(t *testing.T) *srcdom.Program {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":          "module example.com/app\n\ngo 1.21\n",
+		"domain/user.go":  "package domain\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/infra\"\n)\n\nvar _ = fmt.Sprint\nvar _ = infra.DB\n",
+		"domain/debug.go": "//go:build debug\n\npackage domain\n\nimport \"example.com/app/util\"\n\nvar _ = util.X\n",
+		"domain/trace.go": "//go:build trace\n\npackage domain\n\nimport \"example.com/app/infra\"\n\nvar _ = infra.DB\n",
+		"infra/db.go":     "package infra\n\nvar DB int\n",
+		"util/util.go":    "package util\n\nimport \"example.com/app/domain\"\n\nvar X = domain.Y\n",
+		"a/a.go":          "package a\n\nimport \"example.com/app/b\"\n\nvar _ = b.B\n",
+		"b/b.go":          "package b\n\nimport \"example.com/app/a\"\n\nvar B = a.A\n",
+	})
+	l := &srcdom.Loader{Config: &srcdom.Config{}}
+	prog, err := l.LoadTree(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prog
+}
+
+func TestImportGraph(t *testing.T) {
+	g := loadDepsTree(t).ImportGraph()
+	if d := cmp.Diff([]string{
+		"example.com/app/a",
+		"example.com/app/b",
+		"example.com/app/domain",
+		"example.com/app/infra",
+		"example.com/app/util",
+	}, g.Packages); d != "" {
+		t.Errorf("unexpected packages: -want +got\n%s", d)
+	}
+	var edges []string
+	for _, e := range g.Edges {
+		s := e.From + " -> " + e.To
+		if e.Constrained {
+			s += " (constrained)"
+		}
+		edges = append(edges, s)
+	}
+	if d := cmp.Diff([]string{
+		"example.com/app/a -> example.com/app/b",
+		"example.com/app/b -> example.com/app/a",
+		"example.com/app/domain -> example.com/app/infra",
+		"example.com/app/domain -> example.com/app/util (constrained)",
+		"example.com/app/domain -> fmt",
+		"example.com/app/util -> example.com/app/domain",
+	}, edges); d != "" {
+		t.Errorf("unexpected edges: -want +got\n%s", d)
+	}
+	if _, ok := g.Edge("example.com/app/domain", "fmt"); !ok {
+		t.Error("edge to fmt not found")
+	}
+}
+
+func TestImportGraphCycles(t *testing.T) {
+	g := loadDepsTree(t).ImportGraph()
+	var got []string
+	for _, c := range g.Cycles() {
+		s := c.String()
+		if c.Constrained {
+			s += " (constrained)"
+		}
+		got = append(got, s)
+	}
+	if d := cmp.Diff([]string{
+		"example.com/app/a -> example.com/app/b -> example.com/app/a",
+		"example.com/app/domain -> example.com/app/util -> example.com/app/domain (constrained)",
+	}, got); d != "" {
+		t.Errorf("unexpected cycles: -want +got\n%s", d)
+	}
+}
+
+func TestImportGraphCyclesMixed(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":   "module example.com/app\n\ngo 1.21\n",
+		"a/a.go":   "package a\n\nimport \"example.com/app/b\"\n\nvar A = b.B\n",
+		"a/foo.go": "//go:build foo\n\npackage a\n\nimport \"example.com/app/c\"\n\nvar _ = c.C\n",
+		"b/b.go":   "package b\n\nimport \"example.com/app/a\"\n\nvar B = a.A\n",
+		"c/c.go":   "package c\n\nimport \"example.com/app/a\"\n\nvar C = a.A\n",
+	})
+	l := &srcdom.Loader{Config: &srcdom.Config{}}
+	prog, err := l.LoadTree(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range prog.ImportGraph().Cycles() {
+		s := c.String()
+		if c.Constrained {
+			s += " (constrained)"
+		}
+		got = append(got, s)
+	}
+	if d := cmp.Diff([]string{
+		"example.com/app/a -> example.com/app/b -> example.com/app/a",
+		"example.com/app/a -> example.com/app/c -> example.com/app/a (constrained)",
+	}, got); d != "" {
+		t.Errorf("unexpected cycles: -want +got\n%s", d)
+	}
+}
+
+func TestImportGraphCheckLayers(t *testing.T) {
+	g := loadDepsTree(t).ImportGraph()
+	rules, err := srcdom.ParseLayerRules(strings.NewReader(`
+# domain must not import infra
+deny ./domain/... -> ./infra/...
+deny ./domain -> example.com/app/u...
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations, err := g.CheckLayers(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type violation struct {
+		Rule        int
+		From, To    string
+		File        string
+		Line        int
+		Constrained bool
+	}
+	var got []violation
+	for _, v := range violations {
+		got = append(got, violation{
+			Rule:        v.Rule.Line,
+			From:        v.From,
+			To:          v.To,
+			File:        filepath.Base(filepath.Dir(v.Pos.Filename)) + "/" + filepath.Base(v.Pos.Filename),
+			Line:        v.Pos.Line,
+			Constrained: v.Constrained,
+		})
+	}
+	if d := cmp.Diff([]violation{
+		{Rule: 3, From: "example.com/app/domain", To: "example.com/app/infra", File: "domain/user.go", Line: 6},
+		{Rule: 3, From: "example.com/app/domain", To: "example.com/app/infra", File: "domain/trace.go", Line: 5, Constrained: true},
+		{Rule: 4, From: "example.com/app/domain", To: "example.com/app/util", File: "domain/debug.go", Line: 5, Constrained: true},
+	}, got); d != "" {
+		t.Errorf("unexpected violations: -want +got\n%s", d)
+	}
+}
+
+func TestParseLayerRulesError(t *testing.T) {
+	_, err := srcdom.ParseLayerRules(strings.NewReader("deny a b\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 1:") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package srcdom
+
+import (
+	"context"
+	"errors"
+	"go/scanner"
+	"go/token"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// Severity is severity of a Diagnostic.
+type Severity int
+
+const (
+	// SeverityInfo is for informational messages.
+	SeverityInfo Severity = iota
+	// SeverityWarning is for constructs which srcdom doesn't support, but
+	// can be skipped.
+	SeverityWarning
+	// SeverityError is for errors which abort reading without tolerant mode.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "Severity(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// Codes of Diagnostic, which can be used to classify diagnostics.
+const (
+	// CodeParse is for syntax errors which reported by go/parser.
+	CodeParse = "parse"
+	// CodeBuildConstraint is for malformed build constraints.
+	CodeBuildConstraint = "build-constraint"
+	// CodeUnsupported is for constructs which srcdom can't read.
+	CodeUnsupported = "unsupported"
+	// CodeUnsupportedExpr is for type expressions which srcdom can't
+	// convert into string.
+	CodeUnsupportedExpr = "unsupported-expr"
+	// CodeUnsupportedSpec is for specs which srcdom ignores.
+	CodeUnsupportedSpec = "unsupported-spec"
+	// CodeFilteredPackage is for a directory which has no packages
+	// after filtering files by build constraints.
+	CodeFilteredPackage = "filtered-package"
+	// CodeEmbed is for invalid "//go:embed" directives and patterns.
+	CodeEmbed = "embed"
+	// CodeGenerate is for invalid "//go:generate" directives.
+	CodeGenerate = "generate"
+	// CodeModule is for go.mod which can't be read.
+	CodeModule = "module"
+	// CodeCache is for failures of Loader's cache, which don't affect to
+	// read packages.
+	CodeCache = "cache"
+)
+
+// Diagnostic represents a problem which found while reading sources.
+type Diagnostic struct {
+	Pos      token.Position
+	Severity Severity
+	Code     string
+	Message  string
+}
+
+// String returns a string representation of the diagnostic, in the form of
+// "file:line:column: message".
+func (d *Diagnostic) String() string {
+	if !d.Pos.IsValid() && d.Pos.Filename == "" {
+		return d.Message
+	}
+	return d.Pos.String() + ": " + d.Message
+}
+
+// DiagnosticHandler receives diagnostics which reported while reading
+// sources.
+type DiagnosticHandler interface {
+	HandleDiagnostic(d *Diagnostic)
+}
+
+// DiagnosticHandlerFunc is an adapter to use ordinary functions as
+// DiagnosticHandler.
+type DiagnosticHandlerFunc func(d *Diagnostic)
+
+// HandleDiagnostic calls f(d).
+func (f DiagnosticHandlerFunc) HandleDiagnostic(d *Diagnostic) {
+	f(d)
+}
+
+type slogHandler struct {
+	h slog.Handler
+}
+
+// SlogHandler returns a DiagnosticHandler which emits diagnostics as
+// records of slog.Handler.
+func SlogHandler(h slog.Handler) DiagnosticHandler {
+	return &slogHandler{h: h}
+}
+
+func (sh *slogHandler) HandleDiagnostic(d *Diagnostic) {
+	var level slog.Level
+	switch d.Severity {
+	case SeverityInfo:
+		level = slog.LevelInfo
+	case SeverityWarning:
+		level = slog.LevelWarn
+	default:
+		level = slog.LevelError
+	}
+	ctx := context.Background()
+	if !sh.h.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), level, d.Message, 0)
+	r.AddAttrs(slog.String("code", d.Code))
+	if d.Pos.IsValid() || d.Pos.Filename != "" {
+		r.AddAttrs(slog.String("pos", d.Pos.String()))
+	}
+	sh.h.Handle(ctx, r)
+}
+
+// toDiagnostics converts an error into diagnostics.  scanner.ErrorList is
+// expanded into each scanner.Error.
+func toDiagnostics(err error) []*Diagnostic {
+	var list scanner.ErrorList
+	if errors.As(err, &list) {
+		diags := make([]*Diagnostic, 0, len(list))
+		for _, e := range list {
+			diags = append(diags, &Diagnostic{
+				Pos:      e.Pos,
+				Severity: SeverityError,
+				Code:     CodeParse,
+				Message:  e.Msg,
+			})
+		}
+		return diags
+	}
+	var e *scanner.Error
+	if errors.As(err, &e) {
+		return []*Diagnostic{{
+			Pos:      e.Pos,
+			Severity: SeverityError,
+			Code:     CodeParse,
+			Message:  e.Msg,
+		}}
+	}
+	return []*Diagnostic{{
+		Severity: SeverityError,
+		Code:     CodeParse,
+		Message:  err.Error(),
+	}}
+}
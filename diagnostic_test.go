@@ -0,0 +1,162 @@
+package srcdom_test
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/koron-go/srcdom"
+)
+
+func TestTolerantReadDir(t *testing.T) {
+	dir := filepath.Join("_testdata", "tolerant")
+	if _, err := srcdom.ReadDir(dir, false); err == nil {
+		t.Fatal("ReadDir should fail without tolerant mode")
+	}
+
+	cfg := &srcdom.Config{Tolerant: true}
+	pkg, err := cfg.ReadDir(dir, false)
+	if err != nil {
+		t.Fatalf("tolerant ReadDir failed: %s", err)
+	}
+	if pkg.Name != "tolerant" {
+		t.Errorf("unexpected package name: %s", pkg.Name)
+	}
+	for _, name := range []string{"Good", "Broken"} {
+		if _, ok := pkg.Type(name); !ok {
+			t.Errorf("type %s should be read", name)
+		}
+	}
+	if _, ok := pkg.Func("Hello"); !ok {
+		t.Error("func Hello should be read")
+	}
+	if len(pkg.Diagnostics) == 0 {
+		t.Fatal("no diagnostics collected")
+	}
+	files := map[string]bool{}
+	for _, d := range pkg.Diagnostics {
+		if !d.Pos.IsValid() {
+			t.Errorf("diagnostic without position: %s", d)
+		}
+		files[filepath.Base(d.Pos.Filename)] = true
+	}
+	for _, name := range []string{"broken.go", "notgo.go"} {
+		if !files[name] {
+			t.Errorf("no diagnostics for %s: %v", name, pkg.Diagnostics)
+		}
+	}
+}
+
+func TestTolerantParser(t *testing.T) {
+	const src = `package foo
+
+type Foo struct {
+	A int    "a"
+	B string "b"
+	C bool
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// make an unsupported tag for field A.
+	st := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	st.Fields.List[0].Tag.Kind = token.INT
+
+	if err := (&srcdom.Parser{}).ScanFile(file); err == nil {
+		t.Fatal("ScanFile should fail without tolerant mode")
+	}
+
+	p := &srcdom.Parser{Fset: fset, Tolerant: true}
+	if err := p.ScanFile(file); err != nil {
+		t.Fatalf("tolerant ScanFile failed: %s", err)
+	}
+	typ, ok := p.Package.Type("Foo")
+	if !ok {
+		t.Fatal("type Foo not found")
+	}
+	if _, ok := typ.Field("A"); ok {
+		t.Error("field A should be skipped")
+	}
+	for _, name := range []string{"B", "C"} {
+		if _, ok := typ.Field(name); !ok {
+			t.Errorf("field %s should be read", name)
+		}
+	}
+	if len(p.Diagnostics) != 1 {
+		t.Fatalf("unexpected diagnostics: %v", p.Diagnostics)
+	}
+	if got, want := p.Diagnostics[0].Pos.Line, 4; got != want {
+		t.Errorf("unexpected line of diagnostic: want=%d got=%d", want, got)
+	}
+}
+
+func TestDiagnosticHandler(t *testing.T) {
+	const src = `package foo
+
+type Foo struct {
+	A (int)
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []*srcdom.Diagnostic
+	p := &srcdom.Parser{
+		Fset: fset,
+		Handler: srcdom.DiagnosticHandlerFunc(func(d *srcdom.Diagnostic) {
+			got = append(got, d)
+		}),
+	}
+	if err := p.ScanFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("unexpected diagnostics: %v", got)
+	}
+	d := got[0]
+	if d.Severity != srcdom.SeverityWarning || d.Code != srcdom.CodeUnsupportedExpr {
+		t.Errorf("unexpected diagnostic: severity=%s code=%s", d.Severity, d.Code)
+	}
+	if got, want := d.String(), "foo.go:4:4: typeString doesn't support: *ast.ParenExpr"; got != want {
+		t.Errorf("unexpected diagnostic:\nwant=%s\ngot=%s", want, got)
+	}
+	if len(p.Diagnostics) != 1 {
+		t.Errorf("diagnostics should be recorded in Parser: %v", p.Diagnostics)
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	bb := &bytes.Buffer{}
+	h := srcdom.SlogHandler(slog.NewTextHandler(bb, &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	h.HandleDiagnostic(&srcdom.Diagnostic{
+		Severity: srcdom.SeverityInfo,
+		Code:     srcdom.CodeFilteredPackage,
+		Message:  "ignored",
+	})
+	h.HandleDiagnostic(&srcdom.Diagnostic{
+		Pos:      token.Position{Filename: "foo.go", Line: 4, Column: 4},
+		Severity: srcdom.SeverityWarning,
+		Code:     srcdom.CodeUnsupportedExpr,
+		Message:  "hello",
+	})
+	if got, want := bb.String(), "level=WARN msg=hello code=unsupported-expr pos=foo.go:4:4\n"; got != want {
+		t.Errorf("unexpected log:\nwant=%q\ngot=%q", want, got)
+	}
+}
@@ -0,0 +1,486 @@
+package srcdom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RelationKind is a kind of relation between types in a Diagram.
+type RelationKind int
+
+// Kinds of relation between types.
+const (
+	// RelationEmbeds is a relation from a type to its embedded type.
+	RelationEmbeds RelationKind = iota + 1
+
+	// RelationField is a relation from a struct to a type of its field.
+	RelationField
+
+	// RelationImplements is a relation from a type to an interface which
+	// the type implements.
+	RelationImplements
+)
+
+var relationKindNames = map[RelationKind]string{
+	RelationEmbeds:     "embeds",
+	RelationField:      "field",
+	RelationImplements: "implements",
+}
+
+func (k RelationKind) String() string {
+	if s, ok := relationKindNames[k]; ok {
+		return s
+	}
+	return "RelationKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// DiagramOptions is options to make a Diagram.
+type DiagramOptions struct {
+	// ExportedOnly includes only exported types, fields and methods.
+	ExportedOnly bool
+
+	// Roots are names of types which the diagram starts from, like "Client"
+	// or "pkg.Client" for a Program.  All types are included when empty.
+	Roots []string
+
+	// Depth limits the number of relations from Roots to types which
+	// included.  It is not limited when zero or less.
+	Depth int
+}
+
+// Diagram is a class diagram of types.
+type Diagram struct {
+	// Name is the name of the diagram, the package name for a Package.
+	Name string
+
+	Nodes []*DiagramNode
+	Edges []*DiagramEdge
+
+	exportedOnly bool
+}
+
+// DiagramNode is a type in a Diagram.
+type DiagramNode struct {
+	// ID identifies the node in the diagram.  It is the type name for a
+	// Package, and qualified with the import path for a Program.
+	ID string
+
+	// Label is the type name, qualified with the package name for a
+	// Program.
+	Label string
+
+	Package *Package
+	Type    *Type
+}
+
+// DiagramEdge is a relation between types in a Diagram.
+type DiagramEdge struct {
+	Kind RelationKind
+	From string
+	To   string
+
+	// Label is the field name for RelationField.
+	Label string
+}
+
+// Diagram makes a class diagram of types in the package.
+func (p *Package) Diagram(opts *DiagramOptions) *Diagram {
+	d := newDiagramBuilder(opts, []*Package{p}, false).build()
+	d.Name = p.Name
+	return d
+}
+
+// Diagram makes a class diagram of types in all packages in the program.
+// Types in other packages are resolved by package names.
+func (prog *Program) Diagram(opts *DiagramOptions) *Diagram {
+	return newDiagramBuilder(opts, prog.Packages, true).build()
+}
+
+type diagramBuilder struct {
+	opts      DiagramOptions
+	pkgs      []*Package
+	qualified bool
+	pkgByName map[string]*Package
+	nodes     map[*Type]*DiagramNode
+}
+
+func newDiagramBuilder(opts *DiagramOptions, pkgs []*Package, qualified bool) *diagramBuilder {
+	b := &diagramBuilder{
+		pkgs:      pkgs,
+		qualified: qualified,
+		pkgByName: map[string]*Package{},
+		nodes:     map[*Type]*DiagramNode{},
+	}
+	if opts != nil {
+		b.opts = *opts
+	}
+	for _, pkg := range pkgs {
+		if _, ok := b.pkgByName[pkg.Name]; ok {
+			// ambiguous names are not resolved.
+			b.pkgByName[pkg.Name] = nil
+			continue
+		}
+		b.pkgByName[pkg.Name] = pkg
+	}
+	return b
+}
+
+func (b *diagramBuilder) pkgKey(pkg *Package) string {
+	switch {
+	case !b.qualified:
+		return ""
+	case pkg.ImportPath != "":
+		return pkg.ImportPath
+	case pkg.Dir != "":
+		return pkg.Dir
+	default:
+		return pkg.Name
+	}
+}
+
+func (b *diagramBuilder) nodeID(pkg *Package, typ *Type) string {
+	if !b.qualified {
+		return typ.Name
+	}
+	return b.pkgKey(pkg) + "." + typ.Name
+}
+
+// resolve finds a type by a name in the package, like "Client", "*Client"
+// or "pkg.Client".
+func (b *diagramBuilder) resolve(pkg *Package, name string) (*Package, *Type) {
+	name = strings.TrimLeft(name, "*")
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	if q, n, ok := strings.Cut(name, "."); ok {
+		if !b.qualified {
+			return nil, nil
+		}
+		pkg = b.pkgByName[q]
+		if pkg == nil {
+			return nil, nil
+		}
+		name = n
+	}
+	typ, ok := pkg.Type(name)
+	if !ok || !typ.Defined {
+		return nil, nil
+	}
+	return pkg, typ
+}
+
+var typeNameRx = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?`)
+
+// referredTypes returns types which referred in a type expression.
+func (b *diagramBuilder) referredTypes(pkg *Package, expr string) []*Type {
+	var list []*Type
+	for _, name := range typeNameRx.FindAllString(expr, -1) {
+		if _, typ := b.resolve(pkg, name); typ != nil {
+			list = append(list, typ)
+		}
+	}
+	return list
+}
+
+// signature returns a signature of the function, which names of types are
+// qualified to compare with methods in other packages.
+func (b *diagramBuilder) signature(pkg *Package, fn *Func) string {
+	return typeNameRx.ReplaceAllStringFunc(fn.signature(), func(name string) string {
+		if p, typ := b.resolve(pkg, name); typ != nil {
+			return b.pkgKey(p) + "." + typ.Name
+		}
+		return name
+	})
+}
+
+// methodSet collects methods of the type, including promoted ones from
+// embedded types.  It returns false when methods of an interface are not
+// determined, because of embedded types out of the diagram.
+func (b *diagramBuilder) methodSet(pkg *Package, typ *Type, set map[string]string, seen map[*Type]bool) bool {
+	if seen[typ] {
+		return true
+	}
+	seen[typ] = true
+	for _, fn := range typ.Methods {
+		if _, ok := set[fn.Name]; !ok {
+			set[fn.Name] = b.signature(pkg, fn)
+		}
+	}
+	ok := true
+	for _, name := range typ.Embeds {
+		p, embedded := b.resolve(pkg, name)
+		if embedded == nil || typ.IsInterface && !embedded.IsInterface {
+			ok = false
+			continue
+		}
+		ok = b.methodSet(p, embedded, set, seen) && ok
+	}
+	return ok
+}
+
+func implements(methods, iface map[string]string) bool {
+	for name, sig := range iface {
+		if methods[name] != sig {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *diagramBuilder) build() *Diagram {
+	d := &Diagram{exportedOnly: b.opts.ExportedOnly}
+	for _, pkg := range b.pkgs {
+		for _, typ := range pkg.Types {
+			if !typ.Defined || b.opts.ExportedOnly && !typ.IsPublic() {
+				continue
+			}
+			label := typ.Name
+			if b.qualified {
+				label = pkg.Name + "." + typ.Name
+			}
+			n := &DiagramNode{ID: b.nodeID(pkg, typ), Label: label, Package: pkg, Type: typ}
+			b.nodes[typ] = n
+			d.Nodes = append(d.Nodes, n)
+		}
+	}
+
+	seen := map[DiagramEdge]bool{}
+	addEdge := func(kind RelationKind, from *DiagramNode, to *Type, label string) {
+		n, ok := b.nodes[to]
+		if !ok {
+			return
+		}
+		e := DiagramEdge{Kind: kind, From: from.ID, To: n.ID, Label: label}
+		if seen[e] {
+			return
+		}
+		seen[e] = true
+		d.Edges = append(d.Edges, &e)
+	}
+	type methods struct {
+		node *DiagramNode
+		set  map[string]string
+	}
+	var ifaces, concretes []methods
+	for _, n := range d.Nodes {
+		for _, name := range n.Type.Embeds {
+			if _, typ := b.resolve(n.Package, name); typ != nil {
+				addEdge(RelationEmbeds, n, typ, "")
+			}
+		}
+		for _, f := range n.Type.Fields {
+			if b.opts.ExportedOnly && !isPublicName(f.Name) {
+				continue
+			}
+			for _, typ := range b.referredTypes(n.Package, f.Type) {
+				addEdge(RelationField, n, typ, f.Name)
+			}
+		}
+		set := map[string]string{}
+		if !b.methodSet(n.Package, n.Type, set, map[*Type]bool{}) && n.Type.IsInterface {
+			continue
+		}
+		if n.Type.IsInterface {
+			if len(set) > 0 {
+				ifaces = append(ifaces, methods{n, set})
+			}
+		} else {
+			concretes = append(concretes, methods{n, set})
+		}
+	}
+	for _, c := range concretes {
+		for _, i := range ifaces {
+			if implements(c.set, i.set) {
+				addEdge(RelationImplements, c.node, i.node.Type, "")
+			}
+		}
+	}
+
+	if len(b.opts.Roots) > 0 {
+		d.limit(b.opts.Roots, b.opts.Depth)
+	}
+	return d
+}
+
+// limit removes nodes which are far from roots.  Relations are followed
+// in both directions.
+func (d *Diagram) limit(roots []string, depth int) {
+	dist := map[string]int{}
+	var queue []string
+	for _, n := range d.Nodes {
+		for _, r := range roots {
+			if r == n.ID || r == n.Label || r == n.Type.Name {
+				dist[n.ID] = 0
+				queue = append(queue, n.ID)
+				break
+			}
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if depth > 0 && dist[id] >= depth {
+			continue
+		}
+		for _, e := range d.Edges {
+			var next string
+			switch id {
+			case e.From:
+				next = e.To
+			case e.To:
+				next = e.From
+			default:
+				continue
+			}
+			if _, ok := dist[next]; !ok {
+				dist[next] = dist[id] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	var nodes []*DiagramNode
+	for _, n := range d.Nodes {
+		if _, ok := dist[n.ID]; ok {
+			nodes = append(nodes, n)
+		}
+	}
+	var edges []*DiagramEdge
+	for _, e := range d.Edges {
+		_, okFrom := dist[e.From]
+		_, okTo := dist[e.To]
+		if okFrom && okTo {
+			edges = append(edges, e)
+		}
+	}
+	d.Nodes, d.Edges = nodes, edges
+}
+
+// members returns descriptions of fields and methods of the node, like
+// "+Name string" and "-close() error".
+func (n *DiagramNode) members(exportedOnly bool) (fields, methods []string) {
+	visibility := func(name string) string {
+		if isPublicName(name) {
+			return "+"
+		}
+		return "-"
+	}
+	for _, f := range n.Type.Fields {
+		if exportedOnly && !isPublicName(f.Name) {
+			continue
+		}
+		fields = append(fields, visibility(f.Name)+f.Name+" "+f.Type)
+	}
+	for _, fn := range n.Type.Methods {
+		if exportedOnly && !fn.IsPublic() {
+			continue
+		}
+		methods = append(methods, visibility(fn.Name)+fn.Name+fn.signature())
+	}
+	return fields, methods
+}
+
+// stereotype returns a stereotype of the node, like "interface".  It is
+// empty for structs.
+func (n *DiagramNode) stereotype() string {
+	switch {
+	case n.Type.IsStruct:
+		return ""
+	case n.Type.IsInterface:
+		return "interface"
+	case n.Type.Alias:
+		return "alias"
+	default:
+		return n.Type.Expr
+	}
+}
+
+var dotEscaper = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`, `{`, `\{`, `}`, `\}`,
+	`|`, `\|`, `<`, `\<`, `>`, `\>`,
+)
+
+// WriteDOT writes the diagram in Graphviz's DOT language.
+func (d *Diagram) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph %s {\n", strconv.Quote(d.Name))
+	fmt.Fprintf(bw, "\tnode [shape=record];\n")
+	for _, n := range d.Nodes {
+		fields, methods := n.members(d.exportedOnly)
+		title := dotEscaper.Replace(n.Label)
+		if s := n.stereotype(); s != "" {
+			title = `\<\<` + dotEscaper.Replace(s) + `\>\>\n` + title
+		}
+		label := "{" + title + "|"
+		for _, s := range fields {
+			label += dotEscaper.Replace(s) + `\l`
+		}
+		label += "|"
+		for _, s := range methods {
+			label += dotEscaper.Replace(s) + `\l`
+		}
+		label += "}"
+		fmt.Fprintf(bw, "\t%s [label=\"%s\"];\n", strconv.Quote(n.ID), label)
+	}
+	for _, e := range d.Edges {
+		var attrs string
+		switch e.Kind {
+		case RelationEmbeds:
+			attrs = `arrowhead=diamond, label="embeds"`
+		case RelationField:
+			attrs = `arrowhead=vee, label=` + strconv.Quote(e.Label)
+		case RelationImplements:
+			attrs = `arrowhead=empty, style=dashed`
+		}
+		fmt.Fprintf(bw, "\t%s -> %s [%s];\n", strconv.Quote(e.From), strconv.Quote(e.To), attrs)
+	}
+	fmt.Fprintf(bw, "}\n")
+	return bw.Flush()
+}
+
+var (
+	mermaidIDRx     = regexp.MustCompile(`[^A-Za-z0-9_]`)
+	mermaidEscaper  = strings.NewReplacer(`{`, `#123;`, `}`, `#125;`, `"`, `#quot;`)
+	mermaidRelation = map[RelationKind]string{
+		RelationEmbeds:     "*--",
+		RelationField:      "-->",
+		RelationImplements: "..|>",
+	}
+)
+
+// WriteMermaid writes the diagram as a Mermaid class diagram.
+func (d *Diagram) WriteMermaid(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	ids := map[string]string{}
+	fmt.Fprintf(bw, "classDiagram\n")
+	for _, n := range d.Nodes {
+		id := mermaidIDRx.ReplaceAllString(n.ID, "_")
+		ids[n.ID] = id
+		if id == n.Label {
+			fmt.Fprintf(bw, "  class %s\n", id)
+		} else {
+			fmt.Fprintf(bw, "  class %s[\"%s\"]\n", id, mermaidEscaper.Replace(n.Label))
+		}
+		if s := n.stereotype(); s != "" {
+			fmt.Fprintf(bw, "  <<%s>> %s\n", mermaidEscaper.Replace(s), id)
+		}
+		fields, methods := n.members(d.exportedOnly)
+		for _, s := range append(fields, methods...) {
+			fmt.Fprintf(bw, "  %s : %s\n", id, mermaidEscaper.Replace(s))
+		}
+	}
+	for _, e := range d.Edges {
+		fmt.Fprintf(bw, "  %s %s %s", ids[e.From], mermaidRelation[e.Kind], ids[e.To])
+		switch e.Kind {
+		case RelationEmbeds:
+			fmt.Fprintf(bw, " : embeds")
+		case RelationField:
+			fmt.Fprintf(bw, " : %s", e.Label)
+		}
+		fmt.Fprintf(bw, "\n")
+	}
+	return bw.Flush()
+}
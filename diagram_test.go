@@ -0,0 +1,188 @@
+package srcdom_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+const diagramSource = `package shop
+
+import "io"
+
+type Named interface {
+	Name() string
+}
+
+type Store interface {
+	Named
+	Find(id ID) (*Item, error)
+}
+
+type ID string
+
+type Base struct {
+	id ID
+}
+
+func (b *Base) Name() string { return "" }
+
+type Item struct {
+	Base
+	Price  int
+	Tags   []string
+	owner  *Owner
+	Parent *Item
+}
+
+type Owner struct {
+	Items map[ID]*Item
+}
+
+type memStore struct {
+	*Base
+	items []Item
+	w     io.Writer
+}
+
+func (s *memStore) Find(id ID) (*Item, error) { return nil, nil }
+`
+
+func diagramEdges(d *srcdom.Diagram) []string {
+	var list []string
+	for _, e := range d.Edges {
+		s := e.From + " " + e.Kind.String() + " " + e.To
+		if e.Label != "" {
+			s += " (" + e.Label + ")"
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+func diagramNodes(d *srcdom.Diagram) []string {
+	var list []string
+	for _, n := range d.Nodes {
+		list = append(list, n.ID)
+	}
+	return list
+}
+
+func TestPackageDiagram(t *testing.T) {
+	pkg, err := srcdom.ReadSource("shop.go", []byte(diagramSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := pkg.Diagram(nil)
+	if d := cmp.Diff([]string{
+		"Named", "Store", "ID", "Base", "Item", "Owner", "memStore",
+	}, diagramNodes(d)); d != "" {
+		t.Errorf("unexpected nodes: -want +got\n%s", d)
+	}
+	if d := cmp.Diff([]string{
+		"Store embeds Named",
+		"Base field ID (id)",
+		"Item embeds Base",
+		"Item field Owner (owner)",
+		"Item field Item (Parent)",
+		"Owner field ID (Items)",
+		"Owner field Item (Items)",
+		"memStore embeds Base",
+		"memStore field Item (items)",
+		"Base implements Named",
+		"Item implements Named",
+		"memStore implements Named",
+		"memStore implements Store",
+	}, diagramEdges(d)); d != "" {
+		t.Errorf("unexpected edges: -want +got\n%s", d)
+	}
+}
+
+func TestPackageDiagramOptions(t *testing.T) {
+	pkg, err := srcdom.ReadSource("shop.go", []byte(diagramSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := pkg.Diagram(&srcdom.DiagramOptions{
+		ExportedOnly: true,
+		Roots:        []string{"Owner"},
+		Depth:        1,
+	})
+	if d := cmp.Diff([]string{"ID", "Item", "Owner"}, diagramNodes(d)); d != "" {
+		t.Errorf("unexpected nodes: -want +got\n%s", d)
+	}
+	if d := cmp.Diff([]string{
+		"Item field Item (Parent)",
+		"Owner field ID (Items)",
+		"Owner field Item (Items)",
+	}, diagramEdges(d)); d != "" {
+		t.Errorf("unexpected edges: -want +got\n%s", d)
+	}
+
+	b := &strings.Builder{}
+	if err := d.WriteMermaid(b); err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(`classDiagram
+  class ID
+  <<string>> ID
+  class Item
+  Item : +Price int
+  Item : +Tags []string
+  Item : +Parent *Item
+  class Owner
+  Owner : +Items map[ID]*Item
+  Item --> Item : Parent
+  Owner --> ID : Items
+  Owner --> Item : Items
+`, b.String()); d != "" {
+		t.Errorf("unexpected mermaid: -want +got\n%s", d)
+	}
+
+	b.Reset()
+	if err := d.WriteDOT(b); err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(`digraph "shop" {
+	node [shape=record];
+	"ID" [label="{\<\<string\>\>\nID||}"];
+	"Item" [label="{Item|+Price int\l+Tags []string\l+Parent *Item\l|}"];
+	"Owner" [label="{Owner|+Items map[ID]*Item\l|}"];
+	"Item" -> "Item" [arrowhead=vee, label="Parent"];
+	"Owner" -> "ID" [arrowhead=vee, label="Items"];
+	"Owner" -> "Item" [arrowhead=vee, label="Items"];
+}
+`, b.String()); d != "" {
+		t.Errorf("unexpected dot: -want +got\n%s", d)
+	}
+}
+
+func TestProgramDiagram(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":         "module example.com/app\n\ngo 1.21\n",
+		"domain/repo.go": "package domain\n\ntype User struct{}\n\ntype Repository interface {\n\tUser(id string) (*User, error)\n}\n",
+		"infra/db.go":    "package infra\n\nimport \"example.com/app/domain\"\n\ntype DB struct {\n\tcache map[string]*domain.User\n}\n\nfunc (db *DB) User(id string) (*domain.User, error) { return nil, nil }\n",
+		"infra/other.go": "package infra\n\ntype User struct{}\n\ntype Fake struct{}\n\nfunc (Fake) User(id string) (*User, error) { return nil, nil }\n",
+	})
+	l := &srcdom.Loader{Config: &srcdom.Config{}}
+	prog, err := l.LoadTree(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := prog.Diagram(nil)
+	if d := cmp.Diff([]string{
+		"example.com/app/infra.DB field example.com/app/domain.User (cache)",
+		"example.com/app/infra.DB implements example.com/app/domain.Repository",
+	}, diagramEdges(d)); d != "" {
+		t.Errorf("unexpected edges: -want +got\n%s", d)
+	}
+	for _, n := range d.Nodes {
+		if n.ID == "example.com/app/infra.DB" && n.Label != "infra.DB" {
+			t.Errorf("unexpected label: %s", n.Label)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package srcdom
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// Directive is a "//name:args" style comment, like "//go:generate", or a
+// marker like "//+kubebuilder:object:root=true".
+type Directive struct {
+	// Name is the first word of the directive, like "go:generate" or
+	// "+kubebuilder:object:root=true".
+	Name string
+
+	// Args is the rest of the directive, spaces are trimmed.
+	Args string
+
+	Pos token.Position
+}
+
+// Prefix returns the part of the name before the first colon, like "go"
+// for "go:generate".
+func (d *Directive) Prefix() string {
+	prefix, _, _ := strings.Cut(strings.TrimPrefix(d.Name, "+"), ":")
+	return prefix
+}
+
+func (d *Directive) String() string {
+	if d.Args == "" {
+		return "//" + d.Name
+	}
+	return "//" + d.Name + " " + d.Args
+}
+
+// parseDirective parses text of a comment as a directive.  A directive
+// starts with "//", then lower case letters or digits followed by a colon,
+// without any spaces.  A marker which starts with "//+" doesn't need the
+// colon, like "//+optional".
+func parseDirective(text string) (name, args string, ok bool) {
+	s, ok := strings.CutPrefix(text, "//")
+	if !ok {
+		return "", "", false
+	}
+	name = s
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		name, args = s[:i], s[i+1:]
+	}
+	marker, isMarker := strings.CutPrefix(name, "+")
+	prefix, rest, hasColon := strings.Cut(marker, ":")
+	if !hasColon && !isMarker || prefix == "" || hasColon && (rest == "" || !isDirectiveChar(rune(rest[0]))) {
+		return "", "", false
+	}
+	for _, c := range prefix {
+		if !isDirectiveChar(c) {
+			return "", "", false
+		}
+	}
+	return name, strings.TrimSpace(args), true
+}
+
+func isDirectiveChar(c rune) bool {
+	return 'a' <= c && c <= 'z' || '0' <= c && c <= '9'
+}
+
+// directives extracts directives from comment groups.  The groups are
+// marked as attached, so those are not reported as file's directives.
+func (p *Parser) directives(groups ...*ast.CommentGroup) []*Directive {
+	var list []*Directive
+	for _, g := range groups {
+		if g == nil {
+			continue
+		}
+		if p.attached == nil {
+			p.attached = map[*ast.CommentGroup]bool{}
+		}
+		p.attached[g] = true
+		list = append(list, p.groupDirectives(g)...)
+	}
+	return list
+}
+
+func (p *Parser) groupDirectives(g *ast.CommentGroup) []*Directive {
+	var list []*Directive
+	for _, c := range g.List {
+		name, args, ok := parseDirective(c.Text)
+		if !ok {
+			continue
+		}
+		list = append(list, &Directive{
+			Name: name,
+			Args: args,
+			Pos:  p.position(c.Pos()),
+		})
+	}
+	return list
+}
+
+// fileDirectives extracts directives which are not attached to any
+// declarations in the file.
+func (p *Parser) fileDirectives(file *ast.File) []*Directive {
+	var list []*Directive
+	for _, g := range file.Comments {
+		if p.attached[g] {
+			continue
+		}
+		list = append(list, p.groupDirectives(g)...)
+	}
+	p.attached = nil
+	return list
+}
+
+// docText returns text of the first comment group which has any text.
+// Directives are not included, as ast.CommentGroup.Text does.
+func docText(groups ...*ast.CommentGroup) string {
+	for _, g := range groups {
+		if s := g.Text(); s != "" {
+			return s
+		}
+	}
+	return ""
+}
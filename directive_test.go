@@ -0,0 +1,122 @@
+package srcdom_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func TestDirectives(t *testing.T) {
+	pkg, err := srcdom.ReadSource("foo.go", []byte(`//go:build linux
+
+//go:generate stringer -type=Color
+package foo
+
+import _ "embed"
+
+// Color is a color.
+//
+//srcdom:enum prefix=Color
+type Color int
+
+//srcdom:values
+const (
+	Red Color = iota
+	// Green is green.
+	//srcdom:name	verde
+	Green
+)
+
+//go:embed hello.txt
+var hello string
+
+// Spec is a spec.
+//+kubebuilder:object:root=true
+type Spec struct {
+	// Meta is metadata.
+	//srcdom:inline
+	Meta `+"`json:\",inline\"`"+`
+
+	//+kubebuilder:validation:Minimum=1
+	Replicas int
+	Name     string //+optional
+}
+
+// Iface is an interface.
+type Iface interface {
+	//go:nosplit
+	Do()
+}
+
+//go:noinline
+func add(a, b int) int {
+	// go:notdirective
+	//not a directive
+	//http://example.com/
+	return a + b
+}
+
+//go:linkname now runtime.nanotime
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	str := func(list []*srcdom.Directive) []string {
+		var s []string
+		for _, d := range list {
+			s = append(s, d.Name+"|"+d.Args)
+		}
+		return s
+	}
+	check := func(label string, want []string, list []*srcdom.Directive) {
+		t.Helper()
+		if d := cmp.Diff(want, str(list)); d != "" {
+			t.Errorf("unmatch directives of %s: -want +got\n%s", label, d)
+		}
+	}
+
+	f, _ := pkg.File("foo.go")
+	check("file", []string{
+		"go:build|linux",
+		"go:generate|stringer -type=Color",
+		"go:linkname|now runtime.nanotime",
+	}, f.Directives)
+
+	color, _ := pkg.Type("Color")
+	check("Color", []string{"srcdom:enum|prefix=Color"}, color.Directives)
+	red, _ := pkg.Value("Red")
+	check("Red", []string{"srcdom:values|"}, red.Directives)
+	green, _ := pkg.Value("Green")
+	check("Green", []string{"srcdom:values|", "srcdom:name|verde"}, green.Directives)
+	hello, _ := pkg.Value("hello")
+	check("hello", []string{"go:embed|hello.txt"}, hello.Directives)
+
+	spec, _ := pkg.Type("Spec")
+	check("Spec", []string{"+kubebuilder:object:root=true|"}, spec.Directives)
+	replicas, _ := spec.Field("Replicas")
+	check("Spec.Replicas", []string{"+kubebuilder:validation:Minimum=1|"}, replicas.Directives)
+	name, _ := spec.Field("Name")
+	check("Spec.Name", []string{"+optional|"}, name.Directives)
+	if n := len(spec.EmbedFields); n != 1 {
+		t.Fatalf("unexpected number of embedded fields: %d", n)
+	}
+	meta := spec.EmbedFields[0]
+	check("Spec.Meta", []string{"srcdom:inline|"}, meta.Directives)
+	if meta.Type != "Meta" || meta.Doc != "Meta is metadata.\n" || meta.Tag.Raw != `json:",inline"` {
+		t.Errorf("unexpected embedded field: %+v", meta)
+	}
+
+	iface, _ := pkg.Type("Iface")
+	do, _ := iface.Method("Do")
+	check("Iface.Do", []string{"go:nosplit|"}, do.Directives)
+
+	add, _ := pkg.Func("add")
+	check("add", []string{"go:noinline|"}, add.Directives)
+	if p := add.Directives[0].Prefix(); p != "go" {
+		t.Errorf("unexpected prefix: %s", p)
+	}
+	if s := spec.Directives[0].Prefix(); s != "kubebuilder" {
+		t.Errorf("unexpected prefix: %s", s)
+	}
+}
@@ -0,0 +1,182 @@
+package srcdom
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteMarkdown writes the document in Markdown.  Anchors are written as
+// HTML elements, so those work on most renderers.
+func (d *PackageDoc) WriteMarkdown(w io.Writer) error {
+	return d.write(&markdownWriter{d: d, w: bufio.NewWriter(w)})
+}
+
+// WriteHTML writes the document as a self-contained HTML page.
+func (d *PackageDoc) WriteHTML(w io.Writer) error {
+	return d.write(&htmlWriter{d: d, w: bufio.NewWriter(w)})
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `#`, `\#`, `|`, `\|`,
+)
+
+type markdownWriter struct {
+	d *PackageDoc
+	w *bufio.Writer
+}
+
+func (m *markdownWriter) begin(title string) {}
+
+func (m *markdownWriter) heading(level int, id, text string) {
+	m.w.WriteString(strings.Repeat("#", level) + " ")
+	if id != "" {
+		m.w.WriteString(`<a id="` + html.EscapeString(id) + `"></a>`)
+	}
+	m.w.WriteString(markdownEscaper.Replace(text) + "\n\n")
+}
+
+func (m *markdownWriter) code(lang, src string) {
+	fence := "```"
+	for strings.Contains(src, fence) {
+		fence += "`"
+	}
+	m.w.WriteString(fence + lang + "\n" + src + "\n" + fence + "\n\n")
+}
+
+func (m *markdownWriter) comment(text string, level int) {
+	m.w.Write(m.d.commentPrinter(level).Markdown(m.d.parseComment(text)))
+	m.w.WriteString("\n")
+}
+
+func (m *markdownWriter) paragraph(text string) {
+	m.w.WriteString(markdownEscaper.Replace(text) + "\n\n")
+}
+
+func (m *markdownWriter) index(items []docIndexItem) {
+	for _, item := range items {
+		if item.nested {
+			m.w.WriteString("  ")
+		}
+		m.w.WriteString("- [" + markdownEscaper.Replace(item.text) + "](#" + item.id + ")\n")
+	}
+	m.w.WriteString("\n")
+}
+
+func (m *markdownWriter) table(header []string, rows [][]string, code []bool) {
+	m.w.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	m.w.WriteString(strings.Repeat("| --- ", len(header)) + "|\n")
+	for _, row := range rows {
+		for i, cell := range row {
+			switch {
+			case cell == "":
+			case code[i]:
+				// "|" must be escaped even in code spans of tables.
+				cell = strings.ReplaceAll(cell, "|", `\|`)
+				if strings.Contains(cell, "`") {
+					cell = "`` " + cell + " ``"
+				} else {
+					cell = "`" + cell + "`"
+				}
+			default:
+				cell = markdownEscaper.Replace(cell)
+			}
+			m.w.WriteString("| " + cell + " ")
+		}
+		m.w.WriteString("|\n")
+	}
+	m.w.WriteString("\n")
+}
+
+func (m *markdownWriter) end() error {
+	return m.w.Flush()
+}
+
+const htmlStyle = `body { font-family: sans-serif; max-width: 60em; margin: 0 auto; padding: 1em; line-height: 1.5; }
+pre { background: #f6f8fa; padding: 0.8em; overflow-x: auto; }
+code { font-family: monospace; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d0d7de; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+`
+
+type htmlWriter struct {
+	d *PackageDoc
+	w *bufio.Writer
+}
+
+func (h *htmlWriter) begin(title string) {
+	h.w.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	h.w.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	h.w.WriteString("<style>\n" + htmlStyle + "</style>\n</head>\n<body>\n")
+}
+
+func (h *htmlWriter) heading(level int, id, text string) {
+	tag := "h" + strconv.Itoa(level)
+	h.w.WriteString("<" + tag)
+	if id != "" {
+		h.w.WriteString(` id="` + html.EscapeString(id) + `"`)
+	}
+	h.w.WriteString(">" + html.EscapeString(text) + "</" + tag + ">\n")
+}
+
+func (h *htmlWriter) code(lang, src string) {
+	h.w.WriteString("<pre><code>" + html.EscapeString(src) + "</code></pre>\n")
+}
+
+func (h *htmlWriter) comment(text string, level int) {
+	h.w.Write(h.d.commentPrinter(level).HTML(h.d.parseComment(text)))
+}
+
+func (h *htmlWriter) paragraph(text string) {
+	h.w.WriteString("<p>" + html.EscapeString(text) + "</p>\n")
+}
+
+func (h *htmlWriter) index(items []docIndexItem) {
+	h.w.WriteString("<ul>\n")
+	for i, item := range items {
+		if item.nested && (i == 0 || !items[i-1].nested) {
+			h.w.WriteString("<ul>\n")
+		}
+		h.w.WriteString(`<li><a href="#` + html.EscapeString(item.id) + `">` + html.EscapeString(item.text) + "</a>")
+		next := i+1 < len(items) && items[i+1].nested
+		switch {
+		case !item.nested && next:
+			// nested items follow in this item.
+			h.w.WriteString("\n")
+			continue
+		case item.nested && !next:
+			h.w.WriteString("</li>\n</ul></li>\n")
+			continue
+		}
+		h.w.WriteString("</li>\n")
+	}
+	h.w.WriteString("</ul>\n")
+}
+
+func (h *htmlWriter) table(header []string, rows [][]string, code []bool) {
+	h.w.WriteString("<table>\n<thead><tr>")
+	for _, s := range header {
+		h.w.WriteString("<th>" + html.EscapeString(s) + "</th>")
+	}
+	h.w.WriteString("</tr></thead>\n<tbody>\n")
+	for _, row := range rows {
+		h.w.WriteString("<tr>")
+		for i, cell := range row {
+			cell = html.EscapeString(cell)
+			if code[i] && cell != "" {
+				cell = "<code>" + cell + "</code>"
+			}
+			h.w.WriteString("<td>" + cell + "</td>")
+		}
+		h.w.WriteString("</tr>\n")
+	}
+	h.w.WriteString("</tbody>\n</table>\n")
+}
+
+func (h *htmlWriter) end() error {
+	h.w.WriteString("</body>\n</html>\n")
+	return h.w.Flush()
+}
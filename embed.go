@@ -0,0 +1,238 @@
+package srcdom
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EmbedKind is a kind of embed variable.
+type EmbedKind int
+
+// Kinds of embed variable.
+const (
+	EmbedString EmbedKind = iota + 1
+	EmbedBytes
+	EmbedFS
+)
+
+var embedKindNames = map[EmbedKind]string{
+	EmbedString: "string",
+	EmbedBytes:  "[]byte",
+	EmbedFS:     "embed.FS",
+}
+
+func (k EmbedKind) String() string {
+	if s, ok := embedKindNames[k]; ok {
+		return s
+	}
+	return "EmbedKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Embed describes a variable which is populated by "//go:embed".
+type Embed struct {
+	Kind EmbedKind
+
+	// Patterns are patterns in "//go:embed" directives, unquoted.
+	Patterns []string
+
+	// Files are files which matched with Patterns, slash separated and
+	// relative to the package directory.  Those are resolved only with
+	// Config.ResolveEmbeds when reading directories.
+	Files []string
+}
+
+// toEmbed makes an Embed for a variable from its "//go:embed" directives.
+// typ is the type in the declaration.  It returns nil when the variable is
+// not an embed variable.
+func (p *Parser) toEmbed(v *Value, typ ast.Expr) *Embed {
+	var patterns []string
+	for _, d := range v.Directives {
+		if d.Name != "go:embed" {
+			continue
+		}
+		list, err := splitQuoted(d.Args)
+		if err != nil {
+			p.report(&Diagnostic{
+				Pos:      d.Pos,
+				Severity: SeverityWarning,
+				Code:     CodeEmbed,
+				Message:  fmt.Sprintf("invalid go:embed of %s: %s", v.Name, err),
+			})
+			continue
+		}
+		patterns = append(patterns, list...)
+	}
+	if len(patterns) == 0 || v.IsConst {
+		return nil
+	}
+	kind, ok := p.embedKind(v.TypeExpr, typ)
+	if !ok {
+		typeExpr := v.TypeExpr
+		if typ != nil {
+			typeExpr = types.ExprString(typ)
+		}
+		p.report(&Diagnostic{
+			Pos:      v.Pos,
+			Severity: SeverityWarning,
+			Code:     CodeEmbed,
+			Message:  fmt.Sprintf("go:embed cannot apply to var of type %s: %s", typeExpr, v.Name),
+		})
+		return nil
+	}
+	return &Embed{Kind: kind, Patterns: patterns}
+}
+
+// embedKind determines a kind of embed variables from its type.
+func (p *Parser) embedKind(typeExpr string, typ ast.Expr) (EmbedKind, bool) {
+	switch typeExpr {
+	case "string":
+		return EmbedString, true
+	case "[]byte":
+		// typeString drops lengths of arrays, so check it is a slice.
+		if at, ok := typ.(*ast.ArrayType); ok && at.Len == nil {
+			return EmbedBytes, true
+		}
+		return 0, false
+	}
+	pkgName, typeName, ok := strings.Cut(typeExpr, ".")
+	if !ok || typeName != "FS" || !p.isEmbedImport(pkgName) {
+		return 0, false
+	}
+	return EmbedFS, true
+}
+
+// isEmbedImport checks name is a name of "embed" package in the file.
+func (p *Parser) isEmbedImport(name string) bool {
+	for _, imp := range p.file.Imports {
+		if imp.Path != "embed" {
+			continue
+		}
+		if imp.Name == name || imp.Name == "" && name == "embed" {
+			return true
+		}
+	}
+	return false
+}
+
+// splitQuoted splits arguments of directives, like "//go:embed".  Those
+// are separated by spaces, and may be quoted with Go's string syntax.
+func splitQuoted(args string) ([]string, error) {
+	return splitArgs(args, true)
+}
+
+// splitArgs splits arguments which separated by spaces.  Arguments may be
+// double-quoted, or back-quoted when backquote is true.
+func splitArgs(args string, backquote bool) ([]string, error) {
+	var list []string
+	for {
+		args = strings.TrimLeftFunc(args, unicode.IsSpace)
+		if args == "" {
+			return list, nil
+		}
+		var pattern string
+		if args[0] == '`' && !backquote {
+			return nil, fmt.Errorf("back-quoted argument is not supported: %s", args)
+		}
+		switch args[0] {
+		case '"', '`':
+			q, err := strconv.QuotedPrefix(args)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted pattern: %s", args)
+			}
+			pattern, _ = strconv.Unquote(q)
+			args = args[len(q):]
+		default:
+			i := strings.IndexFunc(args, unicode.IsSpace)
+			if i < 0 {
+				i = len(args)
+			}
+			pattern, args = args[:i], args[i:]
+		}
+		list = append(list, pattern)
+	}
+}
+
+// resolveEmbeds resolves patterns of all embed variables in the package,
+// against the directory.
+func (c *Config) resolveEmbeds(p *Parser, src dirSource) {
+	for _, v := range p.Package.Values {
+		if v.Embed == nil {
+			continue
+		}
+		files, err := matchEmbedFiles(src.fsys, src.dir, v.Embed.Patterns)
+		if err != nil {
+			p.report(&Diagnostic{
+				Pos:      v.Pos,
+				Severity: SeverityWarning,
+				Code:     CodeEmbed,
+				Message:  err.Error(),
+			})
+		}
+		v.Embed.Files = files
+	}
+}
+
+// matchEmbedFiles lists files which match with patterns, like the go
+// command does.  Files in matched directories are included recursively,
+// except those start with "." or "_" unless the pattern has "all:".
+func matchEmbedFiles(fsys fs.FS, dir string, patterns []string) ([]string, error) {
+	seen := map[string]bool{}
+	var files []string
+	var errs []error
+	for _, pattern := range patterns {
+		all := false
+		if s, ok := strings.CutPrefix(pattern, "all:"); ok {
+			pattern, all = s, true
+		}
+		matches, err := fs.Glob(fsys, path.Join(dir, pattern))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid pattern %q: %w", pattern, err))
+			continue
+		}
+		n := 0
+		add := func(name string) {
+			n++
+			rel := name
+			if dir != "." {
+				rel = strings.TrimPrefix(name, dir+"/")
+			}
+			if !seen[rel] {
+				seen[rel] = true
+				files = append(files, rel)
+			}
+		}
+		for _, m := range matches {
+			err := fs.WalkDir(fsys, m, func(name string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if name != m && !all && strings.IndexAny(d.Name(), "._") == 0 {
+					if d.IsDir() {
+						return fs.SkipDir
+					}
+					return nil
+				}
+				if !d.IsDir() {
+					add(name)
+				}
+				return nil
+			})
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if n == 0 {
+			errs = append(errs, fmt.Errorf("pattern %s: no matching files found", pattern))
+		}
+	}
+	sort.Strings(files)
+	return files, errors.Join(errs...)
+}
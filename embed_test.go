@@ -0,0 +1,92 @@
+package srcdom_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func TestEmbed(t *testing.T) {
+	cfg := &srcdom.Config{ResolveEmbeds: true}
+	pkg, err := cfg.ReadDir("_testdata/embed", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		want *srcdom.Embed
+	}{
+		{"hello", &srcdom.Embed{
+			Kind:     srcdom.EmbedString,
+			Patterns: []string{"hello.txt"},
+			Files:    []string{"hello.txt"},
+		}},
+		{"helloBytes", &srcdom.Embed{
+			Kind:     srcdom.EmbedBytes,
+			Patterns: []string{"hello.txt"},
+			Files:    []string{"hello.txt"},
+		}},
+		{"static", &srcdom.Embed{
+			Kind:     srcdom.EmbedFS,
+			Patterns: []string{"static", "all:static/sub", "*.txt"},
+			Files: []string{
+				"hello.txt",
+				"static/index.html",
+				"static/sub/_partial.html",
+			},
+		}},
+		{"missing", &srcdom.Embed{
+			Kind:     srcdom.EmbedString,
+			Patterns: []string{"missing.txt"},
+		}},
+		{"notEmbed", nil},
+	} {
+		v, ok := pkg.Value(tc.name)
+		if !ok {
+			t.Fatalf("value %s not found", tc.name)
+		}
+		if d := cmp.Diff(tc.want, v.Embed); d != "" {
+			t.Errorf("unmatch embed of %s: -want +got\n%s", tc.name, d)
+		}
+	}
+
+	var codes []string
+	for _, d := range pkg.Diagnostics {
+		codes = append(codes, d.Code)
+	}
+	if d := cmp.Diff([]string{srcdom.CodeEmbed}, codes); d != "" {
+		t.Errorf("unmatch diagnostics: -want +got\n%s", d)
+	}
+}
+
+func TestEmbedNotResolved(t *testing.T) {
+	pkg, err := srcdom.ReadDir("_testdata/embed", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, _ := pkg.Value("static")
+	if v.Embed == nil || v.Embed.Files != nil {
+		t.Errorf("embed should not be resolved: %+v", v.Embed)
+	}
+}
+
+func TestEmbedArray(t *testing.T) {
+	pkg, err := srcdom.ReadSource("foo.go", []byte(`package foo
+
+import _ "embed"
+
+//go:embed hello.txt
+var b [4]byte
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, _ := pkg.Value("b")
+	if v.Embed != nil {
+		t.Errorf("go:embed should not apply to arrays: %+v", v.Embed)
+	}
+	if len(pkg.Diagnostics) != 1 || pkg.Diagnostics[0].Message != "go:embed cannot apply to var of type [4]byte: b" {
+		t.Errorf("unexpected diagnostics: %v", pkg.Diagnostics)
+	}
+}
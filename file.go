@@ -0,0 +1,244 @@
+package srcdom
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// File represents a source file, and records declarations which the file
+// contributes to a Package.
+type File struct {
+	Name string
+
+	// Generated is true when the file has a "// Code generated ... DO NOT
+	// EDIT." comment.
+	Generated bool
+
+	// Doc is the package comment in the file.  It is empty for "_test.go"
+	// files.
+	Doc string
+
+	Imports []*Import
+
+	// Values, Funcs and Types are names of declarations in the file.
+	Values []string
+	Funcs  []string
+	Types  []string
+
+	// Methods are names of methods in the file, in the form of
+	// "{Type}.{Method}".
+	Methods []string
+
+	// Tests are test functions in a "_test.go" file.
+	Tests []*Test
+
+	// Directives are directives which are not attached to declarations,
+	// like "//go:build" or "//go:generate".
+	Directives []*Directive
+
+	// Generates are all "//go:generate" directives in the file.
+	Generates []*GenerateCommand
+
+	// Cgo is true when the file imports "C".
+	Cgo bool
+
+	// CgoDirectives are "#cgo" directives in the cgo preamble.
+	CgoDirectives []*CgoDirective
+
+	// GoVersion is the minimum Go version which required by the build
+	// constraint of the file, like "go1.22" for "//go:build go1.22".
+	GoVersion string
+
+	// Features are language features which used in the file.
+	Features []*Feature
+
+	// Diagnostics are diagnostics which reported while scanning the file.
+	Diagnostics []*Diagnostic
+}
+
+func (p *Package) putFile(f *File) {
+	if p.fileIdx == nil {
+		p.fileIdx = make(map[string]int)
+	}
+	idx := len(p.files)
+	p.fileIdx[f.Name] = idx
+	p.files = append(p.files, f)
+}
+
+// File gets a file which matches with name.
+func (p *Package) File(name string) (*File, bool) {
+	idx, ok := p.fileIdx[name]
+	if !ok {
+		return nil, false
+	}
+	return p.files[idx], true
+}
+
+// Files returns files which consist the package.
+func (p *Package) Files() []*File {
+	return p.files
+}
+
+// FileNames returns sorted names of files in the package.
+func (p *Package) FileNames() []string {
+	return sortedNames(p.fileIdx)
+}
+
+// parserSettings are settings of a Parser, which are kept in a Package to
+// scan files again with same settings.
+type parserSettings struct {
+	tolerant   bool
+	handler    DiagnosticHandler
+	scanBodies bool
+}
+
+func (p *Parser) settings() parserSettings {
+	return parserSettings{
+		tolerant:   p.Tolerant,
+		handler:    p.Handler,
+		scanBodies: p.ScanBodies,
+	}
+}
+
+func (s parserSettings) newParser(pkg *Package, fset *token.FileSet) *Parser {
+	return &Parser{
+		Package:    pkg,
+		Fset:       fset,
+		Tolerant:   s.tolerant,
+		Handler:    s.handler,
+		ScanBodies: s.scanBodies,
+	}
+}
+
+// UpdateFile replaces declarations from a file with ones in file.
+// When the file is not in the package yet, it is added to the package.
+// The file is scanned with same settings as the package was scanned, like
+// Config.ScanBodies.  Positions are resolved by fset, which should be one
+// used to parse file.
+// Type-checked information of the package is not updated.
+func (p *Package) UpdateFile(fset *token.FileSet, name string, file *ast.File) error {
+	if p.Name != "" && p.Name != file.Name.Name {
+		return fmt.Errorf("package name mismatch: %s has %s, want %s", name, file.Name.Name, p.Name)
+	}
+	p.RemoveFile(name)
+	if p.Name == "" {
+		p.Name = file.Name.Name
+	}
+	parser := p.settings.newParser(p, fset)
+	err := parser.scanFile(name, file)
+	if err != nil {
+		// retract partially scanned declarations.
+		p.RemoveFile(name)
+		return err
+	}
+	p.Diagnostics = append(p.Diagnostics, parser.Diagnostics...)
+	p.ResolveRefs()
+	return nil
+}
+
+// RemoveFile removes declarations which the file contributes from the
+// package.  It returns false when the file is not in the package.
+// Type-checked information of the package is not updated.
+func (p *Package) RemoveFile(name string) bool {
+	f, ok := p.File(name)
+	if !ok {
+		return false
+	}
+
+	for _, imp := range f.Imports {
+		p.Imports = removeImport(p.Imports, imp)
+	}
+
+	values := toSet(f.Values)
+	p.Values = filterSlice(p.Values, func(v *Value) bool { return !values[v.Name] })
+	funcs := toSet(f.Funcs)
+	p.Funcs = filterSlice(p.Funcs, func(fn *Func) bool { return !funcs[fn.Name] })
+
+	for _, m := range f.Methods {
+		typeName, methodName, _ := strings.Cut(m, ".")
+		typ, ok := p.Type(typeName)
+		if !ok {
+			continue
+		}
+		typ.Methods = filterSlice(typ.Methods, func(fn *Func) bool { return fn.Name != methodName })
+		typ.reindex()
+	}
+	for _, typeName := range f.Types {
+		typ, ok := p.Type(typeName)
+		if !ok {
+			continue
+		}
+		// keep methods which declared in other files.
+		*typ = Type{Name: typ.Name, Methods: typ.Methods}
+		typ.reindex()
+	}
+	p.Types = filterSlice(p.Types, func(typ *Type) bool { return typ.Defined || len(typ.Methods) > 0 })
+
+	// parse errors are reported before the file is scanned, so those are
+	// matched by the name.
+	p.Diagnostics = filterSlice(p.Diagnostics, func(d *Diagnostic) bool {
+		return d.Pos.Filename != name && !containsDiagnostic(f.Diagnostics, d)
+	})
+
+	p.files = filterSlice(p.files, func(x *File) bool { return x != f })
+	p.reindex()
+	p.joinDoc()
+	p.ResolveRefs()
+	return true
+}
+
+// joinDoc rebuilds the package comment from ones in files.
+func (p *Package) joinDoc() {
+	var docs []string
+	for _, f := range p.files {
+		if f.Doc != "" {
+			docs = append(docs, f.Doc)
+		}
+	}
+	p.Doc = strings.Join(docs, "\n")
+}
+
+// containsDiagnostic checks a diagnostic which equals to d is in list.
+// Diagnostics are compared by values, because those are not shared after
+// loaded from a cache.
+func containsDiagnostic(list []*Diagnostic, d *Diagnostic) bool {
+	for _, x := range list {
+		if *x == *d {
+			return true
+		}
+	}
+	return false
+}
+
+// removeImport removes the first import which equals to imp.  Positions
+// are compared too, to keep same imports in other files.
+func removeImport(imports []*Import, imp *Import) []*Import {
+	for i, x := range imports {
+		if x == imp || *x == *imp {
+			return append(imports[:i:i], imports[i+1:]...)
+		}
+	}
+	return imports
+}
+
+func toSet(names []string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+// filterSlice returns a new slice which contains elements which satisfy
+// keep.
+func filterSlice[T any](src []T, keep func(T) bool) []T {
+	var dst []T
+	for _, v := range src {
+		if keep(v) {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,209 @@
+package srcdom_test
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func TestPackageUpdateFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo/a.go": {Data: []byte(`package foo
+
+import "io"
+
+type Foo struct {
+	R io.Reader
+}
+
+func (f *Foo) A() {}
+
+func NewFoo() *Foo { return nil }
+
+var A = 1
+`)},
+		"foo/b.go": {Data: []byte(`package foo
+
+import "fmt"
+
+func (f *Foo) B() { fmt.Println() }
+
+type Bar int
+
+const B = 2
+`)},
+	}
+	pkg, err := srcdom.ReadFS(fsys, "foo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]string{"foo/a.go", "foo/b.go"}, pkg.FileNames()); d != "" {
+		t.Fatalf("unmatch FileNames(): -want +got\n%s", d)
+	}
+	f, _ := pkg.File("foo/b.go")
+	if d := cmp.Diff([]string{"Foo.B"}, f.Methods); d != "" {
+		t.Errorf("unmatch methods of b.go: -want +got\n%s", d)
+	}
+
+	// update b.go
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo/b.go", `package foo
+
+func (f *Foo) C() {}
+
+func Baz() {}
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pkg.UpdateFile(fset, "foo/b.go", file); err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]string{"Foo"}, pkg.TypeNames()); d != "" {
+		t.Errorf("unmatch TypeNames(): -want +got\n%s", d)
+	}
+	if d := cmp.Diff([]string{"Baz", "NewFoo"}, pkg.FuncNames()); d != "" {
+		t.Errorf("unmatch FuncNames(): -want +got\n%s", d)
+	}
+	if d := cmp.Diff([]string{"A"}, pkg.ValueNames()); d != "" {
+		t.Errorf("unmatch ValueNames(): -want +got\n%s", d)
+	}
+	foo, _ := pkg.Type("Foo")
+	for name, want := range map[string]bool{"A": true, "B": false, "C": true} {
+		if _, ok := foo.Method(name); ok != want {
+			t.Errorf("method Foo.%s: want=%t got=%t", name, want, ok)
+		}
+	}
+	if len(pkg.Imports) != 1 || pkg.Imports[0].Path != "io" {
+		t.Errorf("unexpected imports: %+v", pkg.Imports)
+	}
+
+	// remove a.go, which defines Foo.
+	if !pkg.RemoveFile("foo/a.go") {
+		t.Fatal("RemoveFile failed")
+	}
+	if pkg.RemoveFile("foo/a.go") {
+		t.Error("RemoveFile should fail for removed file")
+	}
+	foo, ok := pkg.Type("Foo")
+	if !ok {
+		t.Fatal("Foo should be kept for methods in b.go")
+	}
+	if foo.Defined || len(foo.Fields) != 0 {
+		t.Errorf("Foo should be undefined: %+v", foo)
+	}
+	if len(foo.Methods) != 1 || foo.Methods[0].Name != "C" {
+		t.Errorf("unexpected methods of Foo: %+v", foo.Methods)
+	}
+	if d := cmp.Diff([]string{"Baz"}, pkg.FuncNames()); d != "" {
+		t.Errorf("unmatch FuncNames(): -want +got\n%s", d)
+	}
+	if len(pkg.ValueNames()) != 0 || len(pkg.Imports) != 0 {
+		t.Errorf("values and imports should be removed: %v %+v", pkg.ValueNames(), pkg.Imports)
+	}
+
+	// package name should match.
+	file2, _ := parser.ParseFile(fset, "foo/c.go", "package bar\n", 0)
+	if err := pkg.UpdateFile(fset, "foo/c.go", file2); err == nil {
+		t.Error("UpdateFile should fail for another package")
+	}
+}
+
+func TestPackageUpdateFileDiagnostics(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo/a.go": {Data: []byte("package foo\n")},
+	}
+	pkg, err := srcdom.ReadFS(fsys, "foo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := `package foo
+
+import _ "embed"
+
+//go:embed a.txt
+var X int
+
+func Baz() {}
+`
+	// diagnostics from b.go should be replaced on each update.
+	for i := 0; i < 2; i++ {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "foo/b.go", src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pkg.UpdateFile(fset, "foo/b.go", file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(pkg.Diagnostics) != 1 {
+		t.Fatalf("unexpected diagnostics: %v", pkg.Diagnostics)
+	}
+	if d := pkg.Diagnostics[0]; d.Code != srcdom.CodeEmbed || d.Pos.Filename != "foo/b.go" || d.Pos.Line != 6 {
+		t.Errorf("unexpected diagnostic: %+v", d)
+	}
+	baz, _ := pkg.Func("Baz")
+	if baz.Pos.Filename != "foo/b.go" || baz.Pos.Line != 8 {
+		t.Errorf("unexpected position of Baz: %+v", baz.Pos)
+	}
+
+	pkg.RemoveFile("foo/b.go")
+	if len(pkg.Diagnostics) != 0 {
+		t.Errorf("diagnostics should be removed: %v", pkg.Diagnostics)
+	}
+}
+
+func TestPackageUpdateFileDoc(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo/a.go": {Data: []byte("// Package foo is foo.\npackage foo\n")},
+		"foo/b.go": {Data: []byte("// More about foo.\npackage foo\n")},
+	}
+	pkg, err := srcdom.ReadFS(fsys, "foo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "foo/b.go", "// More about foo.\npackage foo\n", parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pkg.UpdateFile(fset, "foo/b.go", file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := cmp.Diff("Package foo is foo.\n\nMore about foo.\n", pkg.Doc); d != "" {
+		t.Errorf("unmatch doc: -want +got\n%s", d)
+	}
+	pkg.RemoveFile("foo/a.go")
+	if d := cmp.Diff("More about foo.\n", pkg.Doc); d != "" {
+		t.Errorf("unmatch doc after RemoveFile: -want +got\n%s", d)
+	}
+}
+
+func TestPackageRemoveFileImports(t *testing.T) {
+	pkg, err := srcdom.ReadFS(fstest.MapFS{
+		"foo/a.go": {Data: []byte("package foo\n\nimport \"io\"\n\nvar _ io.Reader\n")},
+		"foo/b.go": {Data: []byte("package foo\n\n\nimport \"io\"\n\nvar _ io.Writer\n")},
+	}, "foo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b.go is updated to remove its import of "io".
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo/b.go", "package foo\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pkg.UpdateFile(fset, "foo/b.go", file); err != nil {
+		t.Fatal(err)
+	}
+	if len(pkg.Imports) != 1 || pkg.Imports[0].Pos.Filename != "foo/a.go" {
+		t.Errorf("import in a.go should be kept: %+v", pkg.Imports)
+	}
+}
@@ -0,0 +1,100 @@
+package srcdom
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// GenerateCommand is a "//go:generate" directive.
+type GenerateCommand struct {
+	// Line is the command line as written, after "//go:generate ".
+	Line string
+
+	// Args are words of the command line.  $GOFILE, $GOPACKAGE, $GOLINE
+	// and $DOLLAR are expanded, and other variables are kept as is,
+	// because those depend on the environment.
+	Args []string
+
+	// File is a name of the file which has the directive.
+	File string
+
+	Pos token.Position
+}
+
+// GenerateCommands returns all "//go:generate" directives in the package,
+// in order of files and lines.
+func (p *Package) GenerateCommands() []*GenerateCommand {
+	var list []*GenerateCommand
+	for _, f := range p.files {
+		list = append(list, f.Generates...)
+	}
+	return list
+}
+
+// scanGenerates collects "//go:generate" directives in all comments of a
+// file, regardless of those are attached to declarations or not.  As same
+// as "go generate", only comments which start at the beginning of lines
+// are directives.
+func (p *Parser) scanGenerates(name string, file *ast.File) []*GenerateCommand {
+	var list []*GenerateCommand
+	for _, g := range file.Comments {
+		for _, c := range g.List {
+			line, ok := generateLine(c.Text)
+			if !ok {
+				continue
+			}
+			pos := p.position(c.Pos())
+			if pos.Column > 1 {
+				continue
+			}
+			// "go generate" accepts only double-quoted arguments.
+			args, err := splitArgs(line, false)
+			if err != nil {
+				p.report(&Diagnostic{
+					Pos:      pos,
+					Severity: SeverityWarning,
+					Code:     CodeGenerate,
+					Message:  fmt.Sprintf("invalid go:generate: %s", err),
+				})
+				continue
+			}
+			vars := map[string]string{
+				"GOFILE":    filepath.Base(name),
+				"GOPACKAGE": file.Name.Name,
+				"GOLINE":    strconv.Itoa(pos.Line),
+				"DOLLAR":    "$",
+			}
+			for i, arg := range args {
+				args[i] = os.Expand(arg, func(v string) string {
+					if s, ok := vars[v]; ok {
+						return s
+					}
+					return "$" + v
+				})
+			}
+			list = append(list, &GenerateCommand{
+				Line: line,
+				Args: args,
+				File: name,
+				Pos:  pos,
+			})
+		}
+	}
+	return list
+}
+
+// generateLine returns the command line of a "//go:generate" comment.  The
+// name should be followed by a space or a tab, like "go generate" does.
+func generateLine(text string) (string, bool) {
+	for _, prefix := range []string{"//go:generate ", "//go:generate\t"} {
+		if strings.HasPrefix(text, prefix) {
+			return strings.TrimSpace(text[len(prefix):]), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,77 @@
+package srcdom_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/koron-go/srcdom"
+)
+
+func TestGenerateCommands(t *testing.T) {
+	pkg, err := srcdom.ReadSource("dir/color.go", []byte(`package color
+
+//go:generate stringer -type=Color -output=$GOFILE.string.go
+
+// Color is a color.
+//
+//go:generate go run ./gen -pkg $GOPACKAGE -line $GOLINE "hello world" $HOME $DOLLAR
+type Color int
+
+func f() {
+	//go:generate echo in body
+}
+
+//go:generated not a directive
+//go:generate
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*srcdom.GenerateCommand{
+		{
+			Line: "stringer -type=Color -output=$GOFILE.string.go",
+			Args: []string{"stringer", "-type=Color", "-output=color.go.string.go"},
+			File: "dir/color.go",
+		},
+		{
+			Line: `go run ./gen -pkg $GOPACKAGE -line $GOLINE "hello world" $HOME $DOLLAR`,
+			Args: []string{"go", "run", "./gen", "-pkg", "color", "-line", "7", "hello world", "$HOME", "$"},
+			File: "dir/color.go",
+		},
+	}
+	got := pkg.GenerateCommands()
+	if d := cmp.Diff(want, got, cmpopts.IgnoreFields(srcdom.GenerateCommand{}, "Pos")); d != "" {
+		t.Errorf("unmatch generate commands: -want +got\n%s", d)
+	}
+	var lines []int
+	for _, c := range got {
+		lines = append(lines, c.Pos.Line)
+	}
+	if d := cmp.Diff([]int{3, 7}, lines); d != "" {
+		t.Errorf("unmatch lines: -want +got\n%s", d)
+	}
+}
+
+func TestGenerateCommandsInvalid(t *testing.T) {
+	pkg, err := srcdom.ReadSource("foo.go", []byte("package foo\n\n//go:generate echo `raw`\n//go:generate echo \"unterminated\n//go:generate echo ok\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, c := range pkg.GenerateCommands() {
+		lines = append(lines, c.Line)
+	}
+	if d := cmp.Diff([]string{"echo ok"}, lines); d != "" {
+		t.Errorf("invalid commands should be skipped: -want +got\n%s", d)
+	}
+	var diags []int
+	for _, d := range pkg.Diagnostics {
+		if d.Code == srcdom.CodeGenerate {
+			diags = append(diags, d.Pos.Line)
+		}
+	}
+	if d := cmp.Diff([]int{3, 4}, diags); d != "" {
+		t.Errorf("unmatch diagnostics: -want +got\n%s", d)
+	}
+}
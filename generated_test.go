@@ -0,0 +1,65 @@
+package srcdom_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/koron-go/srcdom"
+)
+
+func TestGenerated(t *testing.T) {
+	pkg, err := srcdom.ReadDir("_testdata/generated", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"color.go":        false,
+		"color_string.go": true,
+	} {
+		f, ok := pkg.File(filepath.Join("_testdata", "generated", name))
+		if !ok {
+			t.Fatalf("file %s not found", name)
+		}
+		if f.Generated != want {
+			t.Errorf("Generated of file %s should be %t", name, want)
+		}
+	}
+
+	color, _ := pkg.Type("Color")
+	if color.Generated {
+		t.Error("type Color should not be generated")
+	}
+	if m, _ := color.Method("String"); !m.Generated {
+		t.Error("method Color.String should be generated")
+	}
+	if typ, _ := pkg.Type("colorNames"); !typ.Generated {
+		t.Error("type colorNames should be generated")
+	}
+	if fn, _ := pkg.Func("names"); !fn.Generated {
+		t.Error("func names should be generated")
+	}
+	if v, _ := pkg.Value("_Color_name"); !v.Generated {
+		t.Error("const _Color_name should be generated")
+	}
+	if v, _ := pkg.Value("Red"); v.Generated {
+		t.Error("const Red should not be generated")
+	}
+}
+
+func TestSkipGenerated(t *testing.T) {
+	cfg := &srcdom.Config{SkipGenerated: true}
+	pkg, err := cfg.ReadDir("_testdata/generated", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := pkg.FileNames(); len(names) != 1 {
+		t.Errorf("generated files should be skipped: %v", names)
+	}
+	color, _ := pkg.Type("Color")
+	if len(color.Methods) != 0 {
+		t.Errorf("methods in generated files should be skipped: %d", len(color.Methods))
+	}
+	if _, ok := pkg.Value("_Color_name"); ok {
+		t.Error("values in generated files should be skipped")
+	}
+}
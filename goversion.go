@@ -0,0 +1,181 @@
+package srcdom
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// Feature is a language feature which used in a file, and requires a Go
+// version.
+type Feature struct {
+	// Name is a name of the feature, like "generics".
+	Name string
+
+	// GoVersion is the minimum Go version for the feature, like "go1.18".
+	GoVersion string
+
+	// Pos is the position of the first use in the file.
+	Pos token.Position
+}
+
+// Language features which srcdom detects.  Those are detected only by
+// syntax, so ranging over integers and functions are found only when the
+// ranged expression is a literal.
+var (
+	featureNumberLiterals = &Feature{Name: "number literals", GoVersion: "go1.13"}
+	featureTypeAliases    = &Feature{Name: "type aliases", GoVersion: "go1.9"}
+	featureGenerics       = &Feature{Name: "generics", GoVersion: "go1.18"}
+	featureRangeOverInt   = &Feature{Name: "range over int", GoVersion: "go1.22"}
+	featureRangeOverFunc  = &Feature{Name: "range over func", GoVersion: "go1.23"}
+	featureGenericAliases = &Feature{Name: "generic type aliases", GoVersion: "go1.24"}
+)
+
+// MinGoVersion returns the minimum Go version which the file requires, by
+// its build constraint and features.  It is empty when there are no
+// requirements.
+func (f *File) MinGoVersion() string {
+	v := f.GoVersion
+	for _, feat := range f.Features {
+		v = maxGoVersion(v, feat.GoVersion)
+	}
+	return v
+}
+
+// MinGoVersion returns the minimum Go version which the package requires,
+// by all of its files.  The module's Go version is not included, see
+// ModuleGoVersion.
+func (p *Package) MinGoVersion() string {
+	v := ""
+	for _, f := range p.files {
+		v = maxGoVersion(v, f.MinGoVersion())
+	}
+	return v
+}
+
+// scanFeatures detects language features which used in the file.
+func (p *Parser) scanFeatures(file *ast.File) []*Feature {
+	var list []*Feature
+	found := map[*Feature]bool{}
+	use := func(feat *Feature, pos token.Pos) {
+		if found[feat] {
+			return
+		}
+		found[feat] = true
+		list = append(list, &Feature{Name: feat.Name, GoVersion: feat.GoVersion, Pos: p.position(pos)})
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.TypeSpec:
+			if n.Assign.IsValid() {
+				use(featureTypeAliases, n.Pos())
+			}
+			if n.TypeParams != nil {
+				use(featureGenerics, n.Pos())
+				if n.Assign.IsValid() {
+					use(featureGenericAliases, n.Pos())
+				}
+			}
+		case *ast.FuncType:
+			if n.TypeParams != nil {
+				use(featureGenerics, n.Pos())
+			}
+		case *ast.RangeStmt:
+			switch x := n.X.(type) {
+			case *ast.BasicLit:
+				if x.Kind == token.INT {
+					use(featureRangeOverInt, n.Pos())
+				}
+			case *ast.FuncLit:
+				use(featureRangeOverFunc, n.Pos())
+			}
+		case *ast.BasicLit:
+			if isNewNumberLiteral(n) {
+				use(featureNumberLiterals, n.Pos())
+			}
+		}
+		return true
+	})
+	return list
+}
+
+// isNewNumberLiteral checks the literal uses binary, octal with "0o" or
+// hexadecimal floating-point prefixes, or digit separators.
+func isNewNumberLiteral(x *ast.BasicLit) bool {
+	switch x.Kind {
+	case token.INT, token.FLOAT, token.IMAG:
+	default:
+		return false
+	}
+	s := strings.ToLower(x.Value)
+	if strings.HasPrefix(s, "0b") || strings.HasPrefix(s, "0o") || strings.ContainsRune(s, '_') {
+		return true
+	}
+	return strings.HasPrefix(s, "0x") && strings.ContainsRune(s, 'p')
+}
+
+// parseGoVersion parses a Go version like "go1.21", "go1.21.3" or
+// "go1.22rc1".  A prerelease is a negative number for patch.  A version
+// without "go" prefix is accepted too, like go.mod's one.
+func parseGoVersion(v string) (nums [3]int, ok bool) {
+	s := strings.TrimPrefix(v, "go")
+	for _, pre := range []string{"rc", "beta"} {
+		if i := strings.Index(s, pre); i > 0 {
+			n, err := strconv.Atoi(s[i+len(pre):])
+			if err != nil {
+				return nums, false
+			}
+			// "go1.22rc1" is before "go1.22.0" (and "go1.22").
+			nums[2] = n - 1000
+			if pre == "beta" {
+				nums[2] -= 1000
+			}
+			s = s[:i]
+			break
+		}
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nums, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nums, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+// compareGoVersion compares two Go versions.  Invalid versions are less
+// than any valid versions.
+func compareGoVersion(a, b string) int {
+	na, okA := parseGoVersion(a)
+	nb, okB := parseGoVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := range na {
+		if na[i] != nb[i] {
+			if na[i] < nb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func maxGoVersion(a, b string) string {
+	if compareGoVersion(a, b) < 0 {
+		return b
+	}
+	return a
+}
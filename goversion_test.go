@@ -0,0 +1,101 @@
+package srcdom_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func TestGoVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go.mod": {Data: []byte("module example.com/foo\n\ngo 1.21\n\ntoolchain go1.22.3\n")},
+		"pkg/old.go": {Data: []byte(`package pkg
+
+const Mask = 0b1010
+
+type Alias = int
+`)},
+		"pkg/new.go": {Data: []byte(`//go:build go1.22
+
+package pkg
+
+func Sum[T int | float64](list ...T) T {
+	var sum T
+	for _, v := range list {
+		sum += v
+	}
+	return sum
+}
+
+func Count() int {
+	n := 0
+	for range 10 {
+		n++
+	}
+	return n
+}
+`)},
+		"pkg/iter.go": {Data: []byte(`package pkg
+
+func Seq() {
+	for v := range func(yield func(int) bool) {} {
+		_ = v
+	}
+}
+`)},
+	}
+	pkg, err := srcdom.ReadFS(fsys, "pkg", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.ModuleGoVersion != "go1.21" {
+		t.Errorf("unexpected ModuleGoVersion: %q", pkg.ModuleGoVersion)
+	}
+
+	features := func(f *srcdom.File) []string {
+		var s []string
+		for _, feat := range f.Features {
+			s = append(s, feat.Name+" "+feat.GoVersion)
+		}
+		return s
+	}
+	for _, tc := range []struct {
+		name     string
+		version  string
+		features []string
+		min      string
+	}{
+		{"pkg/iter.go", "", []string{"range over func go1.23"}, "go1.23"},
+		{"pkg/new.go", "go1.22", []string{"generics go1.18", "range over int go1.22"}, "go1.22"},
+		{"pkg/old.go", "", []string{"number literals go1.13", "type aliases go1.9"}, "go1.13"},
+	} {
+		f, ok := pkg.File(tc.name)
+		if !ok {
+			t.Fatalf("file %s not found", tc.name)
+		}
+		if f.GoVersion != tc.version {
+			t.Errorf("unexpected GoVersion of %s: %q", tc.name, f.GoVersion)
+		}
+		if d := cmp.Diff(tc.features, features(f)); d != "" {
+			t.Errorf("unmatch features of %s: -want +got\n%s", tc.name, d)
+		}
+		if v := f.MinGoVersion(); v != tc.min {
+			t.Errorf("unexpected MinGoVersion of %s: %q", tc.name, v)
+		}
+	}
+	if v := pkg.MinGoVersion(); v != "go1.23" {
+		t.Errorf("unexpected MinGoVersion of package: %q", v)
+	}
+}
+
+func TestModuleGoVersionOfRepository(t *testing.T) {
+	pkg, err := srcdom.ReadDir("_testdata/typecheck", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.ModuleGoVersion != "go1.21" {
+		t.Errorf("unexpected ModuleGoVersion: %q", pkg.ModuleGoVersion)
+	}
+}
@@ -0,0 +1,173 @@
+package srcdom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Loader loads packages in many directories concurrently.
+type Loader struct {
+	// Config is used to read each package.
+	Config *Config
+
+	// Workers is the number of packages which are read concurrently.
+	// runtime.GOMAXPROCS(0) is used when it is zero or less.
+	Workers int
+
+	// CacheDir is a directory to store read packages.  The cache is keyed
+	// by contents of source files, so unchanged packages are not parsed
+	// again.  It is stored per package not per file, so all files of a
+	// package are parsed again when one of them is changed.  The cache is
+	// disabled when CacheDir is empty, or Config.TypeCheck or
+	// Config.ResolveEmbeds is enabled.
+	CacheDir string
+}
+
+func (l *Loader) workers() int {
+	if l.Workers > 0 {
+		return l.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+func (l *Loader) cache() *packageCache {
+	if l.CacheDir == "" || l.Config.typeCheck() || l.Config != nil && l.Config.ResolveEmbeds {
+		return nil
+	}
+	return &packageCache{dir: l.CacheDir}
+}
+
+// Load reads packages in dirs concurrently.  Directories which have no
+// packages are not included in the result.  When some directories failed
+// to read, it returns a Program with packages which succeeded, and an
+// error which joins all errors.
+func (l *Loader) Load(ctx context.Context, dirs ...string) (*Program, error) {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		prog = &Program{}
+		errs []error
+	)
+	tags := getTags()
+	cache := l.cache()
+	ch := make(chan string)
+	for i := 0; i < l.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for dir := range ch {
+				if ctx.Err() != nil {
+					continue
+				}
+				pkg, err := l.loadDir(dir, tags, cache)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", dir, err))
+				} else if pkg.Name != "" {
+					prog.putPackage(pkg)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+loop:
+	for _, dir := range dirs {
+		select {
+		case ch <- dir:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(ch)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	prog.sortPackages()
+	if len(dirs) > 0 {
+		if err := prog.findModule(dirs[0]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return prog, errors.Join(errs...)
+}
+
+// LoadTree reads all packages under the root directory concurrently.
+// It skips "testdata" and "vendor" directories, and directories which
+// start with "." or "_", as same as the go command.
+func (l *Loader) LoadTree(ctx context.Context, root string) (*Program, error) {
+	dirs, err := listPackageDirs(root)
+	if err != nil {
+		return nil, err
+	}
+	return l.Load(ctx, dirs...)
+}
+
+func listPackageDirs(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root {
+			name := d.Name()
+			if name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return fs.SkipDir
+			}
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
+
+func (l *Loader) loadDir(dir string, tags map[string]bool, cache *packageCache) (*Package, error) {
+	src := osDirSource(dir)
+	if cache == nil {
+		return readDir(l.Config, src, false, tags)
+	}
+	key, err := l.Config.cacheKey(src, false, tags)
+	if err != nil {
+		return nil, err
+	}
+	if pkg, ok := cache.load(key); ok {
+		pkg.settings = l.Config.newParser(nil).settings()
+		// replay diagnostics, as same as reading sources.
+		if h := l.Config.handler(); h != nil {
+			for _, d := range pkg.Diagnostics {
+				h.HandleDiagnostic(d)
+			}
+		}
+		return pkg, nil
+	}
+	pkg, err := readDir(l.Config, src, false, tags)
+	if err != nil {
+		return nil, err
+	}
+	err = cache.store(key, pkg)
+	if err != nil {
+		// the package is available even if the cache is not.
+		d := &Diagnostic{
+			Severity: SeverityWarning,
+			Code:     CodeCache,
+			Message:  fmt.Sprintf("failed to store cache: %s", err),
+		}
+		pkg.Diagnostics = append(pkg.Diagnostics, d)
+		if h := l.Config.handler(); h != nil {
+			h.HandleDiagnostic(d)
+		}
+	}
+	return pkg, nil
+}
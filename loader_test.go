@@ -0,0 +1,195 @@
+package srcdom_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/koron-go/srcdom"
+)
+
+func TestLoaderLoadTree(t *testing.T) {
+	l := &srcdom.Loader{Config: &srcdom.Config{Tolerant: true}, Workers: 2}
+	prog, err := l.LoadTree(context.Background(), "_testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"_testdata",
+		filepath.Join("_testdata", "cgo"),
+		filepath.Join("_testdata", "embed"),
+		filepath.Join("_testdata", "generated"),
+		filepath.Join("_testdata", "pkgset"),
+		filepath.Join("_testdata", "tolerant"),
+		filepath.Join("_testdata", "typecheck"),
+		filepath.Join("_testdata", "typeerror"),
+		filepath.Join("_testdata", "unused"),
+	}
+	dirs := prog.Dirs()
+	if d := cmp.Diff(want, dirs); d != "" {
+		t.Errorf("unmatch Dirs(): -want +got\n%s", d)
+	}
+	for _, dir := range want {
+		if _, ok := prog.Package(dir); !ok {
+			t.Errorf("package not found: %s", dir)
+		}
+	}
+	for i, pkg := range prog.Packages {
+		if pkg.Dir != dirs[i] {
+			t.Errorf("packages are not sorted: #%d want=%s got=%s", i, dirs[i], pkg.Dir)
+		}
+	}
+}
+
+func TestLoaderCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	srcDir := t.TempDir()
+	src := "package foo\n\ntype Foo struct {\n\tName string `json:\"name\"`\n}\n\nfunc (f *Foo) Hello() {}\n\nconst Bar = 1\n"
+	err := os.WriteFile(filepath.Join(srcDir, "foo.go"), []byte(src), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &srcdom.Loader{CacheDir: cacheDir}
+	prog1, err := l.Load(context.Background(), srcDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(cacheDir, "*", "*.json"))
+	if len(matches) != 1 {
+		t.Fatalf("unexpected cache files: %v", matches)
+	}
+
+	prog2, err := l.Load(context.Background(), srcDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg1, _ := prog1.Package(srcDir)
+	pkg2, ok := prog2.Package(srcDir)
+	if !ok {
+		t.Fatal("package not found in cached program")
+	}
+	opts := cmpopts.IgnoreUnexported(srcdom.Package{}, srcdom.Type{}, srcdom.Tag{})
+	if d := cmp.Diff(pkg1, pkg2, opts); d != "" {
+		t.Errorf("cached package unmatch: -want +got\n%s", d)
+	}
+	typ, ok := pkg2.Type("Foo")
+	if !ok {
+		t.Fatal("indexes are not restored")
+	}
+	if _, ok := typ.Method("Hello"); !ok {
+		t.Error("method index is not restored")
+	}
+	if _, ok := typ.Field("Name"); !ok {
+		t.Error("field index is not restored")
+	}
+
+	// update the source to invalidate cache.
+	err = os.WriteFile(filepath.Join(srcDir, "foo.go"), []byte(src+"\nfunc Baz() {}\n"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog3, err := l.Load(context.Background(), srcDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg3, _ := prog3.Package(srcDir)
+	if _, ok := pkg3.Func("Baz"); !ok {
+		t.Error("updated source is not read")
+	}
+}
+
+func TestLoaderCacheStoreError(t *testing.T) {
+	// a regular file can't be a cache directory.
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	if err := os.WriteFile(cacheDir, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	var got []*srcdom.Diagnostic
+	cfg := &srcdom.Config{Handler: srcdom.DiagnosticHandlerFunc(func(d *srcdom.Diagnostic) {
+		got = append(got, d)
+	})}
+	l := &srcdom.Loader{Config: cfg, CacheDir: cacheDir}
+	prog, err := l.Load(context.Background(), "_testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := prog.Package("_testdata"); !ok {
+		t.Fatal("package not found")
+	}
+	if len(got) != 1 || got[0].Code != srcdom.CodeCache {
+		t.Errorf("unexpected diagnostics: %v", got)
+	}
+}
+
+func TestLoaderCacheDiagnostics(t *testing.T) {
+	var got []string
+	cfg := &srcdom.Config{
+		Tolerant: true,
+		Handler: srcdom.DiagnosticHandlerFunc(func(d *srcdom.Diagnostic) {
+			got = append(got, d.String())
+		}),
+	}
+	l := &srcdom.Loader{Config: cfg, CacheDir: t.TempDir()}
+	dir := filepath.Join("_testdata", "tolerant")
+	if _, err := l.Load(context.Background(), dir); err != nil {
+		t.Fatal(err)
+	}
+	first := got
+	if len(first) == 0 {
+		t.Fatal("no diagnostics reported")
+	}
+	got = nil
+	if _, err := l.Load(context.Background(), dir); err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(first, got); d != "" {
+		t.Errorf("diagnostics are not replayed from cache: -want +got\n%s", d)
+	}
+}
+
+func TestLoaderCacheModule(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":     "module example.com/foo\n\ngo 1.21\n",
+		"bar/bar.go": "package bar\n",
+	})
+	l := &srcdom.Loader{CacheDir: t.TempDir()}
+	dir := filepath.Join(root, "bar")
+	load := func() *srcdom.Package {
+		t.Helper()
+		prog, err := l.Load(context.Background(), dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, ok := prog.Package(dir)
+		if !ok {
+			t.Fatal("package is not loaded")
+		}
+		return pkg
+	}
+	if pkg := load(); pkg.ImportPath != "example.com/foo/bar" {
+		t.Fatalf("unexpected import path: %s", pkg.ImportPath)
+	}
+	// changes of go.mod should not be hidden by the cache.
+	writeFiles(t, root, map[string]string{
+		"go.mod": "module example.com/baz\n\ngo 1.22\n",
+	})
+	pkg := load()
+	if pkg.ImportPath != "example.com/baz/bar" || pkg.ModuleGoVersion != "go1.22" {
+		t.Errorf("stale module information: %s %s", pkg.ImportPath, pkg.ModuleGoVersion)
+	}
+}
+
+func TestLoaderCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l := &srcdom.Loader{}
+	_, err := l.LoadTree(ctx, filepath.Join(runtime.GOROOT(), "src", "go"))
+	if err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,479 @@
+package srcdom
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Module represents a module which described by go.mod.
+type Module struct {
+	// Path is the module path.
+	Path string
+
+	// Dir is the root directory of the module, which contains go.mod.
+	Dir string
+
+	// GoVersion is the Go version of the module, like "go1.21".
+	GoVersion string
+
+	// Toolchain is the toolchain directive, like "go1.22.3".
+	Toolchain string
+
+	Requires []*Require
+	Replaces []*Replace
+	Excludes []*Require
+}
+
+// Require is a required module.
+type Require struct {
+	Path    string
+	Version string
+
+	// Indirect is true when the requirement has "// indirect" comment.
+	Indirect bool
+}
+
+// Replace is a replacement of a module.
+type Replace struct {
+	OldPath    string
+	OldVersion string
+
+	// NewPath is a module path, or a directory path which starts with "./"
+	// or "../" or is absolute.
+	NewPath    string
+	NewVersion string
+}
+
+// Workspace represents a workspace which described by go.work.
+type Workspace struct {
+	// Dir is the root directory of the workspace, which contains go.work.
+	Dir string
+
+	GoVersion string
+	Toolchain string
+
+	// Uses are directories of modules in the workspace, as written.
+	Uses []string
+
+	Replaces []*Replace
+
+	// Modules are modules which read from Uses.
+	Modules []*Module
+}
+
+// ImportClass is a class of an import path.
+type ImportClass int
+
+// Classes of import paths.
+const (
+	ImportStdlib ImportClass = iota + 1
+	ImportSameModule
+	ImportThirdParty
+
+	// ImportCgo is the pseudo package "C" of cgo.
+	ImportCgo
+)
+
+var importClassNames = map[ImportClass]string{
+	ImportStdlib:     "stdlib",
+	ImportSameModule: "same-module",
+	ImportThirdParty: "third-party",
+	ImportCgo:        "cgo",
+}
+
+func (c ImportClass) String() string {
+	if s, ok := importClassNames[c]; ok {
+		return s
+	}
+	return "ImportClass(" + strconv.Itoa(int(c)) + ")"
+}
+
+// isStdlibPath checks an import path is in the standard library.  Like the
+// go command, paths which first element has no dots are standard.
+func isStdlibPath(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".") && importPath != "C"
+}
+
+// Classify classifies an import path.
+func (m *Module) Classify(importPath string) ImportClass {
+	switch {
+	case m.Contains(importPath):
+		return ImportSameModule
+	default:
+		return classifyPath(importPath)
+	}
+}
+
+// classifyPath classifies an import path without modules.
+func classifyPath(importPath string) ImportClass {
+	switch {
+	case importPath == "C":
+		return ImportCgo
+	case isStdlibPath(importPath):
+		return ImportStdlib
+	default:
+		return ImportThirdParty
+	}
+}
+
+// Contains checks an import path is in the module.
+func (m *Module) Contains(importPath string) bool {
+	return importPath == m.Path || strings.HasPrefix(importPath, m.Path+"/")
+}
+
+// Require gets a required module which matches with path.
+func (m *Module) Require(modPath string) (*Require, bool) {
+	for _, r := range m.Requires {
+		if r.Path == modPath {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// importPath returns an import path for a slash separated directory, which
+// relative to the module root.
+func (m *Module) importPath(rel string) string {
+	if rel == "." || rel == "" {
+		return m.Path
+	}
+	return m.Path + "/" + rel
+}
+
+// Module gets a module in the workspace, which contains the import path.
+// The longest module path wins.
+func (w *Workspace) Module(importPath string) (*Module, bool) {
+	var found *Module
+	for _, m := range w.Modules {
+		if m.Contains(importPath) && (found == nil || len(m.Path) > len(found.Path)) {
+			found = m
+		}
+	}
+	return found, found != nil
+}
+
+// ReadModule reads go.mod.  path is a go.mod file or a directory which
+// contains it.
+func ReadModule(path string) (*Module, error) {
+	name, dir := modFilePath(path, "go.mod")
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseModule(name, b)
+	if err != nil {
+		return nil, err
+	}
+	m.Dir = dir
+	return m, nil
+}
+
+// ReadWorkspace reads go.work and go.mod of its modules.  path is a
+// go.work file or a directory which contains it.
+func ReadWorkspace(path string) (*Workspace, error) {
+	name, dir := modFilePath(path, "go.work")
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	w, err := ParseWorkspace(name, b)
+	if err != nil {
+		return nil, err
+	}
+	w.Dir = dir
+	for _, use := range w.Uses {
+		modDir := use
+		if !filepath.IsAbs(modDir) {
+			modDir = filepath.Join(dir, filepath.FromSlash(use))
+		}
+		m, err := ReadModule(modDir)
+		if err != nil {
+			return nil, err
+		}
+		w.Modules = append(w.Modules, m)
+	}
+	return w, nil
+}
+
+func modFilePath(p, base string) (name, dir string) {
+	if fi, err := os.Stat(p); err == nil && fi.IsDir() {
+		return filepath.Join(p, base), p
+	}
+	return p, filepath.Dir(p)
+}
+
+// ParseModule parses contents of go.mod.  name is used for errors.
+func ParseModule(name string, data []byte) (*Module, error) {
+	lines, err := parseModLines(name, data)
+	if err != nil {
+		return nil, err
+	}
+	m := &Module{}
+	for _, l := range lines {
+		switch l.verb {
+		case "module":
+			if len(l.args) != 1 {
+				return nil, l.errorf("usage: module module/path")
+			}
+			m.Path = l.args[0]
+		case "go":
+			if len(l.args) != 1 {
+				return nil, l.errorf("usage: go 1.23")
+			}
+			m.GoVersion = "go" + l.args[0]
+		case "toolchain":
+			if len(l.args) != 1 {
+				return nil, l.errorf("usage: toolchain go1.23.0")
+			}
+			m.Toolchain = l.args[0]
+		case "require", "exclude":
+			if len(l.args) != 2 {
+				return nil, l.errorf("usage: %s module/path v1.2.3", l.verb)
+			}
+			r := &Require{Path: l.args[0], Version: l.args[1], Indirect: l.indirect()}
+			if l.verb == "require" {
+				m.Requires = append(m.Requires, r)
+			} else {
+				m.Excludes = append(m.Excludes, r)
+			}
+		case "replace":
+			r, err := l.toReplace()
+			if err != nil {
+				return nil, err
+			}
+			m.Replaces = append(m.Replaces, r)
+		case "retract", "godebug", "tool", "ignore":
+			// not supported, but valid.
+		default:
+			return nil, l.errorf("unknown directive: %s", l.verb)
+		}
+	}
+	if m.Path == "" {
+		return nil, fmt.Errorf("%s: no module declaration", name)
+	}
+	return m, nil
+}
+
+// ParseWorkspace parses contents of go.work.  name is used for errors.
+func ParseWorkspace(name string, data []byte) (*Workspace, error) {
+	lines, err := parseModLines(name, data)
+	if err != nil {
+		return nil, err
+	}
+	w := &Workspace{}
+	for _, l := range lines {
+		switch l.verb {
+		case "go":
+			if len(l.args) != 1 {
+				return nil, l.errorf("usage: go 1.23")
+			}
+			w.GoVersion = "go" + l.args[0]
+		case "toolchain":
+			if len(l.args) != 1 {
+				return nil, l.errorf("usage: toolchain go1.23.0")
+			}
+			w.Toolchain = l.args[0]
+		case "use":
+			if len(l.args) != 1 {
+				return nil, l.errorf("usage: use local/dir")
+			}
+			w.Uses = append(w.Uses, l.args[0])
+		case "replace":
+			r, err := l.toReplace()
+			if err != nil {
+				return nil, err
+			}
+			w.Replaces = append(w.Replaces, r)
+		case "godebug":
+			// not supported, but valid.
+		default:
+			return nil, l.errorf("unknown directive: %s", l.verb)
+		}
+	}
+	return w, nil
+}
+
+// modLine is a line in go.mod or go.work.  Lines in a block have the verb
+// of the block.
+type modLine struct {
+	name    string
+	num     int
+	verb    string
+	args    []string
+	comment string
+}
+
+func (l *modLine) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", l.name, l.num, fmt.Sprintf(format, args...))
+}
+
+func (l *modLine) indirect() bool {
+	return strings.TrimSpace(l.comment) == "indirect" || strings.HasPrefix(strings.TrimSpace(l.comment), "indirect;")
+}
+
+func (l *modLine) toReplace() (*Replace, error) {
+	old, new, ok := cutArgs(l.args, "=>")
+	if !ok || len(old) < 1 || len(old) > 2 || len(new) < 1 || len(new) > 2 {
+		return nil, l.errorf("usage: replace module/path [v1.2.3] => other/module [v1.4.5]")
+	}
+	r := &Replace{OldPath: old[0], NewPath: new[0]}
+	if len(old) == 2 {
+		r.OldVersion = old[1]
+	}
+	if len(new) == 2 {
+		r.NewVersion = new[1]
+	}
+	return r, nil
+}
+
+func cutArgs(args []string, sep string) (before, after []string, found bool) {
+	for i, a := range args {
+		if a == sep {
+			return args[:i], args[i+1:], true
+		}
+	}
+	return args, nil, false
+}
+
+// parseModLines splits go.mod or go.work into lines, and expands blocks.
+func parseModLines(name string, data []byte) ([]*modLine, error) {
+	var lines []*modLine
+	block := ""
+	for i, text := range strings.Split(string(data), "\n") {
+		num := i + 1
+		text, comment, _ := strings.Cut(text, "//")
+		words, err := splitModWords(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, num, err)
+		}
+		if len(words) == 0 {
+			continue
+		}
+		if block != "" {
+			if len(words) == 1 && words[0] == ")" {
+				block = ""
+				continue
+			}
+			lines = append(lines, &modLine{name: name, num: num, verb: block, args: words, comment: comment})
+			continue
+		}
+		if len(words) == 2 && words[1] == "(" {
+			block = words[0]
+			continue
+		}
+		lines = append(lines, &modLine{name: name, num: num, verb: words[0], args: words[1:], comment: comment})
+	}
+	if block != "" {
+		return nil, fmt.Errorf("%s: unterminated block: %s", name, block)
+	}
+	return lines, nil
+}
+
+// splitModWords splits a line into words.  Words may be quoted.
+func splitModWords(s string) ([]string, error) {
+	var words []string
+	for {
+		s = strings.TrimLeft(s, " \t\r")
+		if s == "" {
+			return words, nil
+		}
+		if s[0] == '"' || s[0] == '`' {
+			q, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted string: %s", s)
+			}
+			w, _ := strconv.Unquote(q)
+			words = append(words, w)
+			s = s[len(q):]
+			continue
+		}
+		i := strings.IndexAny(s, " \t\r")
+		if i < 0 {
+			i = len(s)
+		}
+		words = append(words, s[:i])
+		s = s[i:]
+	}
+}
+
+// findModule finds the nearest go.mod from the directory, and returns the
+// module and slash separated path of the directory relative to the module
+// root.  It returns nil when go.mod is not found.
+func findModule(src dirSource) (*Module, string, error) {
+	if src.base != "" {
+		dir, err := filepath.Abs(src.base)
+		if err != nil {
+			return nil, "", err
+		}
+		for rel := "."; ; {
+			name := filepath.Join(dir, "go.mod")
+			if b, err := os.ReadFile(name); err == nil {
+				m, err := ParseModule(name, b)
+				if err != nil {
+					return nil, "", err
+				}
+				m.Dir = dir
+				return m, rel, nil
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return nil, "", nil
+			}
+			rel = path.Join(filepath.Base(dir), rel)
+			dir = parent
+		}
+	}
+	for dir := src.dir; ; dir = path.Dir(dir) {
+		name := path.Join(dir, "go.mod")
+		if b, err := fs.ReadFile(src.fsys, name); err == nil {
+			m, err := ParseModule(name, b)
+			if err != nil {
+				return nil, "", err
+			}
+			m.Dir = dir
+			rel := strings.TrimPrefix(strings.TrimPrefix(src.dir, dir), "/")
+			if dir == "." {
+				rel = src.dir
+			}
+			return m, rel, nil
+		}
+		if dir == "." || dir == "/" {
+			return nil, "", nil
+		}
+	}
+}
+
+// findModule finds go.mod and go.work from the directory, and sets those to
+// the program.
+func (prog *Program) findModule(dir string) error {
+	m, _, err := findModule(osDirSource(dir))
+	if err != nil {
+		return err
+	}
+	prog.Module = m
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(abs, "go.work")); err == nil {
+			w, err := ReadWorkspace(abs)
+			if err != nil {
+				return err
+			}
+			prog.Workspace = w
+			return nil
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return nil
+		}
+		abs = parent
+	}
+}
@@ -0,0 +1,170 @@
+package srcdom_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func TestParseModule(t *testing.T) {
+	m, err := srcdom.ParseModule("go.mod", []byte(`// comment
+module example.com/foo
+
+go 1.21
+
+toolchain go1.22.3
+
+require github.com/google/go-cmp v0.6.0
+
+require (
+	golang.org/x/mod v0.14.0 // indirect
+	"example.com/quoted" v1.0.0
+)
+
+replace (
+	example.com/quoted => ../quoted
+	golang.org/x/mod v0.14.0 => golang.org/x/mod v0.15.0
+)
+
+exclude example.com/bad v1.2.3
+
+retract v1.0.1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &srcdom.Module{
+		Path:      "example.com/foo",
+		GoVersion: "go1.21",
+		Toolchain: "go1.22.3",
+		Requires: []*srcdom.Require{
+			{Path: "github.com/google/go-cmp", Version: "v0.6.0"},
+			{Path: "golang.org/x/mod", Version: "v0.14.0", Indirect: true},
+			{Path: "example.com/quoted", Version: "v1.0.0"},
+		},
+		Replaces: []*srcdom.Replace{
+			{OldPath: "example.com/quoted", NewPath: "../quoted"},
+			{OldPath: "golang.org/x/mod", OldVersion: "v0.14.0", NewPath: "golang.org/x/mod", NewVersion: "v0.15.0"},
+		},
+		Excludes: []*srcdom.Require{
+			{Path: "example.com/bad", Version: "v1.2.3"},
+		},
+	}
+	if d := cmp.Diff(want, m); d != "" {
+		t.Errorf("unmatch module: -want +got\n%s", d)
+	}
+
+	for path, want := range map[string]srcdom.ImportClass{
+		"fmt":                          srcdom.ImportStdlib,
+		"net/http":                     srcdom.ImportStdlib,
+		"example.com/foo":              srcdom.ImportSameModule,
+		"example.com/foo/sub":          srcdom.ImportSameModule,
+		"example.com/foobar":           srcdom.ImportThirdParty,
+		"github.com/google/go-cmp/cmp": srcdom.ImportThirdParty,
+		"C":                            srcdom.ImportCgo,
+	} {
+		if got := m.Classify(path); got != want {
+			t.Errorf("unexpected class of %s: want=%s got=%s", path, want, got)
+		}
+	}
+}
+
+func TestParseModuleErrors(t *testing.T) {
+	for _, src := range []string{
+		"go 1.21\n",
+		"module a\nrequire (\n",
+		"module a\nunknown x\n",
+		"module a\nreplace x v1\n",
+	} {
+		if _, err := srcdom.ParseModule("go.mod", []byte(src)); err == nil {
+			t.Errorf("should fail: %q", src)
+		}
+	}
+}
+
+func TestPackageImportPath(t *testing.T) {
+	pkg, err := srcdom.ReadDir("_testdata/typecheck", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "github.com/koron-go/srcdom/_testdata/typecheck"; pkg.ImportPath != want {
+		t.Errorf("unexpected ImportPath: %q", pkg.ImportPath)
+	}
+
+	fsys := fstest.MapFS{
+		"go.mod":     {Data: []byte("module example.com/foo\n")},
+		"a/b/foo.go": {Data: []byte("package b\n")},
+	}
+	pkg, err = srcdom.ReadFS(fsys, "a/b", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "example.com/foo/a/b"; pkg.ImportPath != want {
+		t.Errorf("unexpected ImportPath: %q", pkg.ImportPath)
+	}
+}
+
+func TestLoadWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.work":          "go 1.22\n\nuse (\n\t./app\n\t./lib\n)\n",
+		"app/go.mod":       "module example.com/app\n\ngo 1.22\n",
+		"app/main.go":      "package main\n\nimport (\n\t\"fmt\"\n\t\"example.com/lib\"\n\t\"github.com/foo/bar\"\n)\n",
+		"lib/go.mod":       "module example.com/lib\n\ngo 1.21\n",
+		"lib/lib.go":       "package lib\n",
+		"lib/sub/sub.go":   "package sub\n",
+		"app/cmd/cmd.go":   "package main\n",
+		"app/.hidden/x.go": "package x\n",
+	})
+	prog, err := (&srcdom.Loader{}).LoadTree(context.Background(), filepath.Join(dir, "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prog.Module == nil || prog.Module.Path != "example.com/app" {
+		t.Fatalf("unexpected module: %+v", prog.Module)
+	}
+	if prog.Workspace == nil || len(prog.Workspace.Modules) != 2 {
+		t.Fatalf("unexpected workspace: %+v", prog.Workspace)
+	}
+	if d := cmp.Diff([]string{"./app", "./lib"}, prog.Workspace.Uses); d != "" {
+		t.Errorf("unmatch uses: -want +got\n%s", d)
+	}
+	pkg, ok := prog.PackageByImportPath("example.com/app/cmd")
+	if !ok {
+		t.Fatal("package example.com/app/cmd not found")
+	}
+	if pkg.ModuleGoVersion != "go1.22" {
+		t.Errorf("unexpected ModuleGoVersion: %q", pkg.ModuleGoVersion)
+	}
+	main, _ := prog.PackageByImportPath("example.com/app")
+	var got []string
+	for _, imp := range main.Imports {
+		got = append(got, imp.Path+" "+prog.Classify(imp.Path).String())
+	}
+	want := []string{
+		"fmt stdlib",
+		"example.com/lib same-module",
+		"github.com/foo/bar third-party",
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unmatch classes: -want +got\n%s", d)
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, s := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(s), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -4,51 +4,160 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
 	"strconv"
 )
 
 // Parser is a parser for go source files.
 type Parser struct {
 	Package *Package
+
+	// Fset is used to determine positions of diagnostics. It is optional.
+	Fset *token.FileSet
+
+	// Tolerant makes the parser not to stop at unsupported constructs.
+	// Instead of returning errors, those are reported as diagnostics and
+	// scanning continues with the next element.
+	Tolerant bool
+
+	// Handler receives diagnostics when it is set.
+	Handler DiagnosticHandler
+
+	// ScanBodies enables to collect references in function bodies.  After
+	// scanning all files, Package.ResolveRefs should be called.
+	ScanBodies bool
+
+	// Diagnostics holds all diagnostics which reported by the parser.
+	Diagnostics []*Diagnostic
+
+	// file is a File which is being scanned.
+	file *File
+
+	// attached is a set of comment groups which attached to declarations
+	// in the file.
+	attached map[*ast.CommentGroup]bool
 }
 
-func (p *Parser) readImport(s *ast.ImportSpec) error {
+func (p *Parser) position(pos token.Pos) token.Position {
+	if p.Fset == nil || !pos.IsValid() {
+		return token.Position{}
+	}
+	return p.Fset.Position(pos)
+}
+
+// report records a diagnostic, and passes it to Handler.
+func (p *Parser) report(d *Diagnostic) {
+	p.Diagnostics = append(p.Diagnostics, d)
+	if p.file != nil {
+		p.file.Diagnostics = append(p.file.Diagnostics, d)
+	}
+	if p.Handler != nil {
+		p.Handler.HandleDiagnostic(d)
+	}
+}
+
+// warn reports a warning about a node.
+func (p *Parser) warn(node ast.Node, code, msg string) {
+	var pos token.Pos
+	if node != nil {
+		pos = node.Pos()
+	}
+	p.report(&Diagnostic{
+		Pos:      p.position(pos),
+		Severity: SeverityWarning,
+		Code:     code,
+		Message:  msg,
+	})
+}
+
+// fail reports err as a diagnostic then returns nil in tolerant mode.
+// Otherwise it returns err as is.
+func (p *Parser) fail(pos token.Pos, err error) error {
+	if !p.Tolerant {
+		return err
+	}
+	p.report(&Diagnostic{
+		Pos:      p.position(pos),
+		Severity: SeverityError,
+		Code:     CodeUnsupported,
+		Message:  err.Error(),
+	})
+	return nil
+}
+
+func (p *Parser) toImport(s *ast.ImportSpec) (*Import, error) {
 	path, err := strconv.Unquote(s.Path.Value)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	name := ""
 	if s.Name != nil {
 		name = s.Name.Name
 	}
-	p.Package.Imports = append(p.Package.Imports, &Import{
+	return &Import{
 		Name: name,
 		Path: path,
-	})
+		Pos:  p.position(s.Pos()),
+	}, nil
+}
+
+// fileImports returns imports of a file, without scanning the file.
+func (p *Parser) fileImports(file *ast.File) []*Import {
+	var list []*Import
+	for _, s := range file.Imports {
+		if imp, err := p.toImport(s); err == nil {
+			list = append(list, imp)
+		}
+	}
+	return list
+}
+
+func (p *Parser) readImport(s *ast.ImportSpec) error {
+	imp, err := p.toImport(s)
+	if err != nil {
+		return p.fail(s.Path.Pos(), err)
+	}
+	p.Package.Imports = append(p.Package.Imports, imp)
+	p.file.Imports = append(p.file.Imports, imp)
 	return nil
 }
 
 func (p *Parser) readValue(d *ast.GenDecl) error {
 	prev := ""
+	prevExpr := ""
 	for _, spec := range d.Specs {
 		s, ok := spec.(*ast.ValueSpec)
 		if !ok {
-			warnf("readValue not support: %T", spec)
+			p.warn(spec, CodeUnsupportedSpec, fmt.Sprintf("readValue not support: %T", spec))
 			continue
 		}
-		// determine var/const typeName
+		// check is const
+		isConst := d.Tok == token.CONST
+		// determine var/const typeName.  a const spec without values
+		// repeats the type of the previous one.
 		typeName := ""
 		if s.Type != nil {
 			if n, imp := baseTypeName(s.Type); !imp {
 				typeName = n
-				prev = n
 			}
 		}
-		if typeName == "" {
+		if isConst && s.Type == nil && len(s.Values) == 0 {
 			typeName = prev
+		} else {
+			prev = typeName
+		}
+		// determine full type expression.  a const spec without values
+		// repeats the previous one.
+		typeExpr := ""
+		switch {
+		case s.Type != nil:
+			typeExpr = p.typeString(s.Type)
+		case isConst && len(s.Values) == 0:
+			typeExpr = prevExpr
+		}
+		if len(s.Values) > 0 {
+			prevExpr = typeExpr
 		}
-		// check is const
-		isConst := d.Tok == token.CONST
 		// extract basic literal
 		var lit *ast.BasicLit
 		if len(s.Values) == 1 {
@@ -56,22 +165,59 @@ func (p *Parser) readValue(d *ast.GenDecl) error {
 				lit = v
 			}
 		}
-		for _, n := range s.Names {
-			p.Package.putValue(&Value{
-				Name:    n.Name,
-				Type:    typeName,
-				IsConst: isConst,
-				Literal: lit,
-			})
+		for i, n := range s.Names {
+			expr := ""
+			if len(s.Values) == len(s.Names) {
+				expr = types.ExprString(s.Values[i])
+			}
+			v := &Value{
+				Name:     n.Name,
+				Pos:      p.position(n.Pos()),
+				DeclPos:  p.position(d.Pos()),
+				Type:     typeName,
+				TypeExpr: typeExpr,
+				IsConst:  isConst,
+				Literal:  lit,
+				Expr:     expr,
+
+				InTestFile: isTestFile(p.file.Name),
+				Generated:  p.file.Generated,
+				Doc:        docText(s.Doc, d.Doc, s.Comment),
+				Comment:    docText(s.Comment),
+				Directives: p.directives(d.Doc, s.Doc, s.Comment),
+			}
+			v.Embed = p.toEmbed(v, s.Type)
+			if p.ScanBodies {
+				v.Refs = p.scanValue(s, i)
+			}
+			p.file.Values = append(p.file.Values, n.Name)
+			p.Package.putValue(v)
 		}
 	}
 	return nil
 }
 
-func (p *Parser) readType(spec *ast.TypeSpec) error {
+func (p *Parser) readType(d *ast.GenDecl, spec *ast.TypeSpec) error {
 	name := spec.Name.Name
 	typ := p.Package.assureType(name)
 	typ.Defined = true
+	typ.Pos = p.position(spec.Name.Pos())
+	typ.Alias = spec.Assign.IsValid()
+	typ.TypeParams = p.toVarArray(spec.TypeParams)
+	typ.InTestFile = isTestFile(p.file.Name)
+	typ.Generated = p.file.Generated
+	typ.Doc = docText(spec.Doc, d.Doc, spec.Comment)
+	typ.Directives = p.directives(d.Doc, spec.Doc, spec.Comment)
+	switch spec.Type.(type) {
+	case *ast.StructType, *ast.InterfaceType:
+		// those are described by Fields, Methods and Embeds.
+	default:
+		typ.Expr = p.typeString(spec.Type)
+	}
+	if p.ScanBodies {
+		typ.Refs = p.scanType(spec)
+	}
+	p.file.Types = append(p.file.Types, name)
 	return p.readTypeFields(spec.Type, typ)
 }
 
@@ -102,15 +248,21 @@ func (p *Parser) readTypeFields(expr ast.Expr, typ *Type) error {
 func (p *Parser) readStructType(st *ast.StructType, typ *Type) error {
 	typ.IsStruct = true
 	for _, astField := range st.Fields.List {
-		f, err := p.toField(astField)
+		fields, err := p.toFields(astField)
 		if err != nil {
-			return err
+			if err := p.fail(astField.Pos(), err); err != nil {
+				return err
+			}
+			continue
 		}
-		if f.Name == "" {
-			typ.putEmbed(f.Type)
-			break
+		for _, f := range fields {
+			if f.Name == "" {
+				typ.putEmbed(f.Type)
+				typ.EmbedFields = append(typ.EmbedFields, f)
+				continue
+			}
+			typ.putField(f)
 		}
-		typ.putField(f)
 	}
 	return nil
 }
@@ -122,46 +274,76 @@ func (p *Parser) readInterfaceType(it *ast.InterfaceType, typ *Type) error {
 		case *ast.FuncType:
 			// MethodElem
 			name := firstName(astField.Names)
-			typ.putMethod(toFunc(name, ft))
+			fn := p.toFunc(name, ft)
+			fn.Pos = p.position(astField.Pos())
+			fn.Doc = docText(astField.Doc, astField.Comment)
+			fn.Directives = p.directives(astField.Doc, astField.Comment)
+			typ.putMethod(fn)
 		case *ast.SelectorExpr, *ast.Ident, *ast.BinaryExpr:
 			// TypeElem
-			typ.putEmbed(typeString(ft))
+			typ.putEmbed(p.typeString(ft))
 		default:
-			return fmt.Errorf("unsupported interface method type: %T (%s)", ft, typeString(ft))
+			err := p.fail(astField.Pos(), fmt.Errorf("unsupported interface method type: %T (%s)", ft, p.typeString(ft)))
+			if err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
 func (p *Parser) readFunc(fun *ast.FuncDecl) error {
-	f := toFunc(fun.Name.Name, fun.Type)
+	f := p.toFunc(fun.Name.Name, fun.Type)
+	f.TypeParams = p.toVarArray(fun.Type.TypeParams)
+	f.Pos = p.position(fun.Name.Pos())
+	f.InTestFile = isTestFile(p.file.Name)
+	f.Generated = p.file.Generated
+	f.Doc = docText(fun.Doc)
+	f.Directives = p.directives(fun.Doc)
+	if p.ScanBodies {
+		f.Refs = p.scanFunc(fun)
+	}
 	if fun.Recv != nil {
 		if len(fun.Recv.List) == 0 {
 			// should not happen (incorrect AST);
-			return fmt.Errorf("no receivers: %q", fun.Name.Name)
+			return p.fail(fun.Pos(), fmt.Errorf("no receivers: %q", fun.Name.Name))
 		}
 		recvTypeName, imp := baseTypeName(fun.Recv.List[0].Type)
+		if recvTypeName == "" {
+			return p.fail(fun.Pos(), fmt.Errorf("unsupported receiver type: %q", fun.Name.Name))
+		}
 		if imp {
 			// should not happen (incorrect AST);
-			return fmt.Errorf("method fro imported receiver: %q", recvTypeName)
+			return p.fail(fun.Pos(), fmt.Errorf("method fro imported receiver: %q", recvTypeName))
 		}
+		f.Recv = p.typeString(fun.Recv.List[0].Type)
 		p.Package.assureType(recvTypeName).putMethod(f)
+		p.file.Methods = append(p.file.Methods, recvTypeName+"."+f.Name)
 		return nil
 	}
 	p.Package.putFunc(f)
+	p.file.Funcs = append(p.file.Funcs, f.Name)
 	return nil
 }
 
-func (p *Parser) toField(f *ast.Field) (*Field, error) {
+// toFields converts an ast.Field into Fields for each name.  It returns a
+// Field without name for an embedded field.
+func (p *Parser) toFields(f *ast.Field) ([]*Field, error) {
 	tag, err := p.toTag(f.Tag)
 	if err != nil {
 		return nil, err
 	}
-	return &Field{
-		Name: firstName(f.Names),
-		Type: typeString(f.Type),
-		Tag:  tag,
-	}, nil
+	typ := p.typeString(f.Type)
+	doc := docText(f.Doc, f.Comment)
+	directives := p.directives(f.Doc, f.Comment)
+	if len(f.Names) == 0 {
+		return []*Field{{Type: typ, Tag: tag, Pos: p.position(f.Type.Pos()), Doc: doc, Directives: directives}}, nil
+	}
+	fields := make([]*Field, len(f.Names))
+	for i, n := range f.Names {
+		fields[i] = &Field{Name: n.Name, Type: typ, Tag: tag, Pos: p.position(n.Pos()), Doc: doc, Directives: directives}
+	}
+	return fields, nil
 }
 
 func (p *Parser) toTag(x *ast.BasicLit) (*Tag, error) {
@@ -200,7 +382,7 @@ func (p *Parser) readGenDecl(d *ast.GenDecl) error {
 	case token.TYPE:
 		if len(d.Specs) == 1 && !d.Lparen.IsValid() {
 			if s, ok := d.Specs[0].(*ast.TypeSpec); ok {
-				err := p.readType(s)
+				err := p.readType(d, s)
 				if err != nil {
 					return err
 				}
@@ -209,7 +391,7 @@ func (p *Parser) readGenDecl(d *ast.GenDecl) error {
 		}
 		for _, spec := range d.Specs {
 			if s, ok := spec.(*ast.TypeSpec); ok {
-				err := p.readType(s)
+				err := p.readType(d, s)
 				if err != nil {
 					return err
 				}
@@ -220,12 +402,25 @@ func (p *Parser) readGenDecl(d *ast.GenDecl) error {
 }
 
 // ScanFile scans a ast.File to build Package.
+// The name of the file is determined by Fset when it is available.
 func (p *Parser) ScanFile(file *ast.File) error {
+	return p.scanFile(p.position(file.Package).Filename, file)
+}
+
+func (p *Parser) scanFile(name string, file *ast.File) error {
 	if p.Package == nil || p.Package.Name != file.Name.Name {
 		p.Package = &Package{
 			Name: file.Name.Name,
 		}
 	}
+	p.file = &File{Name: name, Generated: ast.IsGenerated(file)}
+	p.Package.putFile(p.file)
+	p.Package.settings = p.settings()
+	defer func() { p.file = nil }()
+	if file.Doc != nil && !isTestFile(name) {
+		p.file.Doc = file.Doc.Text()
+		p.Package.joinDoc()
+	}
 	for _, decl := range file.Decls {
 		switch d := decl.(type) {
 		case *ast.GenDecl:
@@ -240,5 +435,11 @@ func (p *Parser) ScanFile(file *ast.File) error {
 			}
 		}
 	}
+	p.file.Tests = p.scanTests(name, file)
+	p.scanCgo(file)
+	p.file.GoVersion = file.GoVersion
+	p.file.Features = p.scanFeatures(file)
+	p.file.Generates = p.scanGenerates(name, file)
+	p.file.Directives = p.fileDirectives(file)
 	return nil
 }
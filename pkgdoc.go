@@ -0,0 +1,528 @@
+package srcdom
+
+import (
+	"go/doc/comment"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PackageDoc is a reference document of exported API of a package, which
+// organized like "go doc".  Declarations in "_test.go" files are not
+// included, while examples are collected from those.
+type PackageDoc struct {
+	Package *Package
+
+	// Consts and Vars are values which are not grouped by types, in order
+	// of declarations.
+	Consts []*Value
+	Vars   []*Value
+
+	// Funcs are functions sorted by names.
+	Funcs []*Func
+
+	// Types are types sorted by names.
+	Types []*TypeDoc
+
+	examples map[string][]*Test
+}
+
+// TypeDoc is a document of a type in a PackageDoc.
+type TypeDoc struct {
+	Type *Type
+
+	// Consts and Vars are values of the type, in order of declarations.
+	Consts []*Value
+	Vars   []*Value
+
+	// Methods are exported methods sorted by names.
+	Methods []*Func
+}
+
+// Doc makes a reference document of the package.  Examples are collected
+// from both of the package and the external test package.
+func (s *PackageSet) Doc() *PackageDoc {
+	p := s.Package
+	d := &PackageDoc{Package: p, examples: map[string][]*Test{}}
+	types := map[string]*TypeDoc{}
+	for _, typ := range p.Types {
+		if !typ.Defined || !typ.IsPublic() || typ.InTestFile {
+			continue
+		}
+		td := &TypeDoc{Type: typ}
+		for _, m := range typ.Methods {
+			if m.IsPublic() && !m.InTestFile {
+				td.Methods = append(td.Methods, m)
+			}
+		}
+		sort.Slice(td.Methods, func(i, j int) bool {
+			return td.Methods[i].Name < td.Methods[j].Name
+		})
+		types[typ.Name] = td
+		d.Types = append(d.Types, td)
+	}
+	sort.Slice(d.Types, func(i, j int) bool {
+		return d.Types[i].Type.Name < d.Types[j].Type.Name
+	})
+	var values []*Value
+	for _, v := range p.Values {
+		if v.IsPublic() && !v.InTestFile {
+			values = append(values, v)
+		}
+	}
+	// a declaration is attached to a type, only when all values in it
+	// have the type.
+	for _, g := range groupValues(values) {
+		consts, vars := &d.Consts, &d.Vars
+		if td, ok := types[g[0].Type]; ok && sameValueTypes(g) {
+			consts, vars = &td.Consts, &td.Vars
+		}
+		if g[0].IsConst {
+			*consts = append(*consts, g...)
+		} else {
+			*vars = append(*vars, g...)
+		}
+	}
+	for _, fn := range p.Funcs {
+		if fn.IsPublic() && !fn.InTestFile {
+			d.Funcs = append(d.Funcs, fn)
+		}
+	}
+	sort.Slice(d.Funcs, func(i, j int) bool {
+		return d.Funcs[i].Name < d.Funcs[j].Name
+	})
+	for _, pkg := range []*Package{p, s.XTest} {
+		if pkg == nil {
+			continue
+		}
+		for _, t := range pkg.Tests() {
+			if t.Kind == ExampleFunc {
+				d.examples[t.Target] = append(d.examples[t.Target], t)
+			}
+		}
+	}
+	return d
+}
+
+func sameValueTypes(values []*Value) bool {
+	for _, v := range values[1:] {
+		if v.Type != values[0].Type {
+			return false
+		}
+	}
+	return true
+}
+
+// Examples returns examples of a symbol, like "Client" or "Client.Do".
+// Examples of the package itself are returned for "".
+func (d *PackageDoc) Examples(target string) []*Test {
+	return d.examples[target]
+}
+
+// exampleSuffix returns a suffix of the example name, like "retry" for
+// "ExampleClient_Do_retry".
+func exampleSuffix(t *Test) string {
+	s := strings.TrimPrefix(strings.TrimPrefix(t.Name, "Example"), "_")
+	s = strings.TrimPrefix(s, strings.ReplaceAll(t.Target, ".", "_"))
+	return strings.TrimPrefix(s, "_")
+}
+
+// declaration returns the declaration of the function with names of
+// parameters, like "func (*Client) Do(req *Request) error".
+func (fn *Func) declaration() string {
+	b := &strings.Builder{}
+	b.WriteString("func ")
+	if fn.Recv != "" {
+		b.WriteString("(" + fn.Recv + ") ")
+	}
+	b.WriteString(fn.Name + typeParamsString(fn.TypeParams))
+	fn.writeParams(b)
+	return b.String()
+}
+
+func (fn *Func) writeParams(b *strings.Builder) {
+	b.WriteString("(" + varsString(fn.Params) + ")")
+	switch {
+	case len(fn.Results) == 0:
+	case len(fn.Results) == 1 && fn.Results[0].Name == "":
+		b.WriteString(" " + fn.Results[0].Type)
+	default:
+		b.WriteString(" (" + varsString(fn.Results) + ")")
+	}
+}
+
+// typeParamsString returns type parameters in brackets, like
+// "[K comparable, V any]".  Parameters with same constraints are joined,
+// like "[T, U any]".
+func typeParamsString(params []*Var) string {
+	if len(params) == 0 {
+		return ""
+	}
+	var list []string
+	for i, v := range params {
+		if i+1 < len(params) && params[i+1].Type == v.Type {
+			list = append(list, v.Name)
+			continue
+		}
+		list = append(list, v.Name+" "+v.Type)
+	}
+	return "[" + strings.Join(list, ", ") + "]"
+}
+
+func varsString(vars []*Var) string {
+	list := make([]string, len(vars))
+	for i, v := range vars {
+		list[i] = v.Type
+		if v.Name != "" {
+			list[i] = v.Name + " " + v.Type
+		}
+	}
+	return strings.Join(list, ", ")
+}
+
+// declaration returns the declaration of the type with exported fields or
+// methods.
+func (typ *Type) declaration() string {
+	b := &strings.Builder{}
+	b.WriteString("type " + typ.Name + typeParamsString(typ.TypeParams) + " ")
+	switch {
+	case typ.IsStruct:
+		b.WriteString("struct {\n")
+	case typ.IsInterface:
+		b.WriteString("interface {\n")
+	case typ.Alias:
+		b.WriteString("= " + typ.Expr)
+		return b.String()
+	default:
+		b.WriteString(typ.Expr)
+		return b.String()
+	}
+	for _, name := range typ.Embeds {
+		b.WriteString("\t" + name + "\n")
+	}
+	filtered := false
+	for _, f := range typ.Fields {
+		if !isPublicName(f.Name) {
+			filtered = true
+			continue
+		}
+		b.WriteString("\t" + f.Name + " " + f.Type)
+		if f.Tag != nil && f.Tag.Raw != "" {
+			b.WriteString(" " + quoteTag(f.Tag.Raw))
+		}
+		b.WriteString("\n")
+	}
+	for _, m := range typ.Methods {
+		if !typ.IsInterface {
+			break
+		}
+		if !m.IsPublic() {
+			filtered = true
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSpace(m.Doc), "\n") {
+			if line != "" {
+				b.WriteString("\t// " + line + "\n")
+			}
+		}
+		b.WriteString("\t" + m.Name)
+		m.writeParams(b)
+		b.WriteString("\n")
+	}
+	if filtered {
+		b.WriteString("\t// contains filtered or unexported ")
+		if typ.IsInterface {
+			b.WriteString("methods\n")
+		} else {
+			b.WriteString("fields\n")
+		}
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func quoteTag(tag string) string {
+	if strconv.CanBackquote(tag) {
+		return "`" + tag + "`"
+	}
+	return strconv.Quote(tag)
+}
+
+// valuesDeclaration returns a declaration of values, which are grouped
+// with parentheses when there are many.
+func valuesDeclaration(values []*Value) string {
+	comment := func(v *Value) string {
+		if v.Comment == "" {
+			return ""
+		}
+		return " // " + strings.Join(strings.Fields(v.Comment), " ")
+	}
+	spec := func(v *Value) string {
+		if v.IsConst && v.Expr == "" {
+			// repeats the previous expression.
+			return v.Name + comment(v)
+		}
+		s := v.Name
+		if v.TypeExpr != "" {
+			s += " " + v.TypeExpr
+		}
+		if v.Expr != "" {
+			s += " = " + v.Expr
+		}
+		return s + comment(v)
+	}
+	keyword := "var"
+	if values[0].IsConst {
+		keyword = "const"
+	}
+	if len(values) == 1 {
+		return keyword + " " + spec(values[0])
+	}
+	b := &strings.Builder{}
+	b.WriteString(keyword + " (\n")
+	for _, v := range values {
+		b.WriteString("\t" + spec(v) + "\n")
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// formatDecl formats a declaration like gofmt, to align fields and
+// comments.  It returns the declaration as is when it is invalid.
+func formatDecl(src string) string {
+	b, err := format.Source([]byte(src))
+	if err != nil {
+		return src
+	}
+	return string(b)
+}
+
+// groupValues groups consecutive values which declared by a declaration,
+// like values in a parenthesized declaration.
+func groupValues(values []*Value) [][]*Value {
+	var groups [][]*Value
+	for i, v := range values {
+		last := len(groups) - 1
+		if i > 0 && v.DeclPos.IsValid() && v.DeclPos == values[i-1].DeclPos {
+			groups[last] = append(groups[last], v)
+			continue
+		}
+		groups = append(groups, []*Value{v})
+	}
+	return groups
+}
+
+// docAnchor returns an anchor of a symbol, like "Client" or "Client.Do".
+func docAnchor(recv, name string) string {
+	if recv == "" {
+		return name
+	}
+	return recv + "." + name
+}
+
+// recvTypeName returns the base type name of a receiver, like "Client"
+// for "*Client[T]".
+func recvTypeName(recv string) string {
+	s := strings.TrimLeft(recv, "*")
+	if i := strings.IndexByte(s, '['); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// parseComment parses a doc comment.  Links to symbols in the package,
+// like "[Client.Do]", are recognized.
+func (d *PackageDoc) parseComment(text string) *comment.Doc {
+	p := &comment.Parser{
+		LookupSym: func(recv, name string) bool {
+			if recv == "" {
+				if _, ok := d.Package.Type(name); ok {
+					return true
+				}
+				if _, ok := d.Package.Func(name); ok {
+					return true
+				}
+				_, ok := d.Package.Value(name)
+				return ok
+			}
+			typ, ok := d.Package.Type(recv)
+			if !ok {
+				return false
+			}
+			if _, ok := typ.Method(name); ok {
+				return true
+			}
+			_, ok = typ.Field(name)
+			return ok
+		},
+	}
+	return p.Parse(text)
+}
+
+// commentPrinter returns a printer for doc comments.  Links to symbols in
+// the package refer anchors in the document, and others refer pkg.go.dev.
+func (d *PackageDoc) commentPrinter(headingLevel int) *comment.Printer {
+	return &comment.Printer{
+		HeadingLevel: headingLevel,
+		DocLinkURL: func(link *comment.DocLink) string {
+			if link.ImportPath == "" || link.ImportPath == d.Package.ImportPath {
+				return "#" + docAnchor(link.Recv, link.Name)
+			}
+			return link.DefaultURL("https://pkg.go.dev")
+		},
+	}
+}
+
+// docWriter writes elements of a document in a format.
+type docWriter interface {
+	begin(title string)
+	heading(level int, id, text string)
+	code(lang, src string)
+	comment(text string, level int)
+	paragraph(text string)
+	index(items []docIndexItem)
+	table(header []string, rows [][]string, code []bool)
+	end() error
+}
+
+type docIndexItem struct {
+	nested bool
+	id     string
+	text   string
+}
+
+// write writes the document with a docWriter.
+func (d *PackageDoc) write(w docWriter) error {
+	p := d.Package
+	w.begin("package " + p.Name)
+	w.heading(1, "", "package "+p.Name)
+	if p.ImportPath != "" {
+		w.code("go", "import "+strconv.Quote(p.ImportPath))
+	}
+	if p.Doc != "" {
+		w.comment(p.Doc, 3)
+	}
+	d.writeExamples(w, "", 3)
+
+	w.heading(2, "pkg-index", "Index")
+	var items []docIndexItem
+	if len(d.Consts) > 0 {
+		items = append(items, docIndexItem{id: "pkg-constants", text: "Constants"})
+	}
+	if len(d.Vars) > 0 {
+		items = append(items, docIndexItem{id: "pkg-variables", text: "Variables"})
+	}
+	for _, fn := range d.Funcs {
+		items = append(items, docIndexItem{id: fn.Name, text: fn.declaration()})
+	}
+	for _, td := range d.Types {
+		items = append(items, docIndexItem{id: td.Type.Name, text: "type " + td.Type.Name})
+		for _, m := range td.Methods {
+			if td.Type.IsInterface {
+				continue
+			}
+			items = append(items, docIndexItem{nested: true, id: docAnchor(td.Type.Name, m.Name), text: m.declaration()})
+		}
+	}
+	w.index(items)
+
+	if len(d.Consts) > 0 {
+		w.heading(2, "pkg-constants", "Constants")
+		d.writeValues(w, d.Consts)
+	}
+	if len(d.Vars) > 0 {
+		w.heading(2, "pkg-variables", "Variables")
+		d.writeValues(w, d.Vars)
+	}
+	if len(d.Funcs) > 0 {
+		w.heading(2, "pkg-functions", "Functions")
+		for _, fn := range d.Funcs {
+			d.writeFunc(w, fn, 3)
+		}
+	}
+	if len(d.Types) > 0 {
+		w.heading(2, "pkg-types", "Types")
+		for _, td := range d.Types {
+			d.writeType(w, td)
+		}
+	}
+	return w.end()
+}
+
+func (d *PackageDoc) writeValues(w docWriter, values []*Value) {
+	for _, g := range groupValues(values) {
+		w.code("go", formatDecl(valuesDeclaration(g)))
+		// a line comment is written in the declaration.
+		if g[0].Doc != "" && g[0].Doc != g[0].Comment {
+			w.comment(g[0].Doc, 5)
+		}
+	}
+}
+
+func (d *PackageDoc) writeFunc(w docWriter, fn *Func, level int) {
+	id, title := fn.Name, "func "+fn.Name
+	if fn.Recv != "" {
+		id = docAnchor(recvTypeName(fn.Recv), fn.Name)
+		title = "func (" + fn.Recv + ") " + fn.Name
+	}
+	w.heading(level, id, title)
+	w.code("go", formatDecl(fn.declaration()))
+	if fn.Doc != "" {
+		w.comment(fn.Doc, 5)
+	}
+	d.writeExamples(w, id, level+1)
+}
+
+func (d *PackageDoc) writeType(w docWriter, td *TypeDoc) {
+	typ := td.Type
+	w.heading(3, typ.Name, "type "+typ.Name)
+	w.code("go", formatDecl(typ.declaration()))
+	if typ.Doc != "" {
+		w.comment(typ.Doc, 5)
+	}
+	var rows [][]string
+	for _, f := range typ.Fields {
+		if !isPublicName(f.Name) {
+			continue
+		}
+		tag := ""
+		if f.Tag != nil {
+			tag = f.Tag.Raw
+		}
+		rows = append(rows, []string{f.Name, f.Type, tag, strings.Join(strings.Fields(f.Doc), " ")})
+	}
+	if len(rows) > 0 {
+		w.table([]string{"Field", "Type", "Tag", "Description"}, rows, []bool{true, true, true, false})
+	}
+	d.writeValues(w, td.Consts)
+	d.writeValues(w, td.Vars)
+	d.writeExamples(w, typ.Name, 4)
+	if typ.IsInterface {
+		return
+	}
+	for _, m := range td.Methods {
+		d.writeFunc(w, m, 4)
+	}
+}
+
+func (d *PackageDoc) writeExamples(w docWriter, target string, level int) {
+	for _, ex := range d.Examples(target) {
+		title := "Example"
+		if s := exampleSuffix(ex); s != "" {
+			title += " (" + s + ")"
+		}
+		w.heading(level, "example"+strings.TrimPrefix(ex.Name, "Example"), title)
+		if ex.Code != "" {
+			w.code("go", ex.Code)
+		}
+		if ex.HasOutput {
+			if ex.Unordered {
+				w.paragraph("Unordered output:")
+			} else {
+				w.paragraph("Output:")
+			}
+			w.code("", ex.Output)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package srcdom_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func readDocPackage(t *testing.T) *srcdom.PackageDoc {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/shop\n\ngo 1.21\n",
+		"shop.go": `// Package shop sells items.
+package shop
+
+// Kind is a kind of [Item].
+type Kind int
+
+// Kinds of item.
+const (
+	Food Kind = iota // something to eat
+	Tool
+)
+
+// Kinds of item.
+const Other Kind = 9
+
+// MaxItems is the limit of items.
+const MaxItems = 10
+
+// Limits of names.
+const (
+	MinKind    Kind = 0
+	MaxNameLen      = 32
+)
+
+// Item is an item in the shop.
+type Item struct {
+	Name  string ` + "`json:\"name\"`" + ` // Name of the item.
+	Kind  Kind
+	price int
+}
+
+// New creates an [Item].
+func New(name string) *Item { return &Item{Name: name} }
+
+// Price returns the price.
+func (it *Item) Price() int { return it.price }
+
+func (it *Item) discount() {}
+
+func helper() {}
+`,
+		"shop_test.go": "package shop\n\nfunc TestHelper() {}\n\ntype fixture struct{}\n",
+		"example_test.go": `package shop_test
+
+import (
+	"fmt"
+
+	"example.com/shop"
+)
+
+func ExampleNew() {
+	fmt.Println(shop.New("pen").Name)
+	// Output: pen
+}
+`,
+	})
+	set, err := srcdom.ReadPackageSet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set.Doc()
+}
+
+func TestPackageDoc(t *testing.T) {
+	d := readDocPackage(t)
+	var names []string
+	for _, v := range d.Consts {
+		names = append(names, "const "+v.Name)
+	}
+	for _, fn := range d.Funcs {
+		names = append(names, "func "+fn.Name)
+	}
+	for _, td := range d.Types {
+		names = append(names, "type "+td.Type.Name)
+		for _, v := range td.Consts {
+			names = append(names, "  const "+v.Name)
+		}
+		for _, m := range td.Methods {
+			names = append(names, "  method "+m.Name)
+		}
+	}
+	if d := cmp.Diff([]string{
+		"const MaxItems",
+		"const MinKind",
+		"const MaxNameLen",
+		"func New",
+		"type Item",
+		"  method Price",
+		"type Kind",
+		"  const Food",
+		"  const Tool",
+		"  const Other",
+	}, names); d != "" {
+		t.Errorf("unexpected declarations: -want +got\n%s", d)
+	}
+	if n := len(d.Examples("New")); n != 1 {
+		t.Errorf("unexpected number of examples for New: %d", n)
+	}
+}
+
+func TestPackageDocWriteMarkdown(t *testing.T) {
+	d := readDocPackage(t)
+	b := &strings.Builder{}
+	if err := d.WriteMarkdown(b); err != nil {
+		t.Fatal(err)
+	}
+	want := "# package shop\n\n" +
+		"```go\nimport \"example.com/shop\"\n```\n\n" +
+		"Package shop sells items.\n\n" +
+		"## <a id=\"pkg-index\"></a>Index\n\n" +
+		"- [Constants](#pkg-constants)\n" +
+		"- [func New(name string) \\*Item](#New)\n" +
+		"- [type Item](#Item)\n" +
+		"  - [func (\\*Item) Price() int](#Item.Price)\n" +
+		"- [type Kind](#Kind)\n\n" +
+		"## <a id=\"pkg-constants\"></a>Constants\n\n" +
+		"```go\nconst MaxItems = 10\n```\n\n" +
+		"MaxItems is the limit of items.\n\n" +
+		"```go\nconst (\n\tMinKind    Kind = 0\n\tMaxNameLen      = 32\n)\n```\n\n" +
+		"Limits of names.\n\n" +
+		"## <a id=\"pkg-functions\"></a>Functions\n\n" +
+		"### <a id=\"New\"></a>func New\n\n" +
+		"```go\nfunc New(name string) *Item\n```\n\n" +
+		"New creates an [Item](#Item).\n\n" +
+		"#### <a id=\"exampleNew\"></a>Example\n\n" +
+		"```go\nfmt.Println(shop.New(\"pen\").Name)\n```\n\n" +
+		"Output:\n\n" +
+		"```\npen\n```\n\n" +
+		"## <a id=\"pkg-types\"></a>Types\n\n" +
+		"### <a id=\"Item\"></a>type Item\n\n" +
+		"```go\ntype Item struct {\n\tName string `json:\"name\"`\n\tKind Kind\n\t// contains filtered or unexported fields\n}\n```\n\n" +
+		"Item is an item in the shop.\n\n" +
+		"| Field | Type | Tag | Description |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| `Name` | `string` | `json:\"name\"` | Name of the item. |\n" +
+		"| `Kind` | `Kind` |  |  |\n\n" +
+		"#### <a id=\"Item.Price\"></a>func (\\*Item) Price\n\n" +
+		"```go\nfunc (*Item) Price() int\n```\n\n" +
+		"Price returns the price.\n\n" +
+		"### <a id=\"Kind\"></a>type Kind\n\n" +
+		"```go\ntype Kind int\n```\n\n" +
+		"Kind is a kind of [Item](#Item).\n\n" +
+		"```go\nconst (\n\tFood Kind = iota // something to eat\n\tTool\n)\n```\n\n" +
+		"Kinds of item.\n\n" +
+		"```go\nconst Other Kind = 9\n```\n\n" +
+		"Kinds of item.\n\n"
+	if d := cmp.Diff(want, b.String()); d != "" {
+		t.Errorf("unexpected markdown: -want +got\n%s", d)
+	}
+}
+
+func TestPackageDocWriteHTML(t *testing.T) {
+	d := readDocPackage(t)
+	b := &strings.Builder{}
+	if err := d.WriteHTML(b); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, s := range []string{
+		"<title>package shop</title>",
+		`<h3 id="Item">type Item</h3>`,
+		`<li><a href="#Item">type Item</a>` + "\n" + `<ul>` + "\n" + `<li><a href="#Item.Price">func (*Item) Price() int</a></li>` + "\n</ul></li>\n",
+		`<td><code>Name</code></td><td><code>string</code></td><td><code>json:&#34;name&#34;</code></td><td>Name of the item.</td>`,
+		`<p>New creates an <a href="#Item">Item</a>.`,
+		"</body>\n</html>\n",
+	} {
+		if !strings.Contains(got, s) {
+			t.Errorf("HTML doesn't contain %q", s)
+		}
+	}
+}
+
+func TestPackageDocGenerics(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/coll\n\ngo 1.21\n",
+		"coll.go": `package coll
+
+// List is a list.
+type List[T any] struct {
+	Items []T
+}
+
+// Map maps values.
+func Map[T, U any](s []T, f func(T) U) []U { return nil }
+
+// Keys returns keys.
+func Keys[K comparable, V any](m map[K]V) []K { return nil }
+`,
+	})
+	set, err := srcdom.ReadPackageSet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &strings.Builder{}
+	if err := set.Doc().WriteMarkdown(b); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, s := range []string{
+		"```go\nfunc Map[T, U any](s []T, f func(T) U) []U\n```",
+		"```go\nfunc Keys[K comparable, V any](m map[K]V) []K\n```",
+		"```go\ntype List[T any] struct {\n\tItems []T\n}\n```",
+	} {
+		if !strings.Contains(got, s) {
+			t.Errorf("markdown doesn't contain %q", s)
+		}
+	}
+}
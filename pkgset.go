@@ -0,0 +1,30 @@
+package srcdom
+
+import "strings"
+
+// PackageSet is a set of packages in a directory: the package with its
+// in-package test files, and the external test package.
+type PackageSet struct {
+	// Package is the package, which includes declarations in in-package
+	// "_test.go" files.  Those are marked with InTestFile.
+	Package *Package
+
+	// XTest is the external test package, which is named "{name}_test".
+	// It is nil when the directory has no such package.
+	XTest *Package
+}
+
+// TestFiles returns "_test.go" files in the package.
+func (p *Package) TestFiles() []*File {
+	var files []*File
+	for _, f := range p.files {
+		if isTestFile(f.Name) {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+func isTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go")
+}
@@ -0,0 +1,89 @@
+package srcdom_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func TestReadPackageSet(t *testing.T) {
+	set, err := srcdom.ReadPackageSet("_testdata/pkgset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, xtest := set.Package, set.XTest
+	if pkg.Name != "pkgset" {
+		t.Errorf("unexpected package name: %s", pkg.Name)
+	}
+	if xtest == nil || xtest.Name != "pkgset_test" {
+		t.Fatalf("unexpected external test package: %+v", xtest)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		inTest bool
+	}{
+		{"Client", false},
+		{"fakeClient", true},
+	} {
+		typ, ok := pkg.Type(tc.name)
+		if !ok {
+			t.Fatalf("type %s not found", tc.name)
+		}
+		if typ.InTestFile != tc.inTest {
+			t.Errorf("InTestFile of type %s should be %t", tc.name, tc.inTest)
+		}
+	}
+	client, _ := pkg.Type("Client")
+	for _, tc := range []struct {
+		name   string
+		inTest bool
+	}{
+		{"Do", false},
+		{"reset", true},
+	} {
+		m, ok := client.Method(tc.name)
+		if !ok {
+			t.Fatalf("method %s not found", tc.name)
+		}
+		if m.InTestFile != tc.inTest {
+			t.Errorf("InTestFile of method %s should be %t", tc.name, tc.inTest)
+		}
+	}
+	if v, _ := pkg.Value("Max"); v.InTestFile {
+		t.Error("InTestFile of Max should be false")
+	}
+	if v, _ := pkg.Value("testMax"); !v.InTestFile {
+		t.Error("InTestFile of testMax should be true")
+	}
+
+	var testFiles []string
+	for _, f := range pkg.TestFiles() {
+		testFiles = append(testFiles, f.Name)
+	}
+	if d := cmp.Diff([]string{filepath.Join("_testdata", "pkgset", "helper_test.go")}, testFiles); d != "" {
+		t.Errorf("unmatch TestFiles(): -want +got\n%s", d)
+	}
+
+	var tests []string
+	for _, pkg := range []*srcdom.Package{pkg, xtest} {
+		for _, test := range pkg.Tests() {
+			tests = append(tests, test.Name)
+		}
+	}
+	if d := cmp.Diff([]string{"TestDo", "ExampleClient_Do"}, tests); d != "" {
+		t.Errorf("unmatch tests: -want +got\n%s", d)
+	}
+}
+
+func TestReadPackageSetNoXTest(t *testing.T) {
+	set, err := srcdom.ReadPackageSet("_testdata/typecheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.Package == nil || set.XTest != nil {
+		t.Errorf("unexpected packages: %+v", set)
+	}
+}
@@ -0,0 +1,91 @@
+package srcdom
+
+import "sort"
+
+// Program represents a set of packages, which are indexed by directories.
+type Program struct {
+	Packages []*Package
+	pkgIdx   map[string]int
+
+	// Module is the module which contains the loaded directories.  It is
+	// nil when go.mod is not found.
+	Module *Module
+
+	// Workspace is the workspace which contains the loaded directories.
+	// It is nil when go.work is not found.
+	Workspace *Workspace
+}
+
+func (prog *Program) putPackage(pkg *Package) {
+	if prog.pkgIdx == nil {
+		prog.pkgIdx = make(map[string]int)
+	}
+	if idx, ok := prog.pkgIdx[pkg.Dir]; ok {
+		prog.Packages[idx] = pkg
+		return
+	}
+	idx := len(prog.Packages)
+	prog.pkgIdx[pkg.Dir] = idx
+	prog.Packages = append(prog.Packages, pkg)
+}
+
+func (prog *Program) removePackage(dir string) {
+	idx, ok := prog.pkgIdx[dir]
+	if !ok {
+		return
+	}
+	prog.Packages = append(prog.Packages[:idx:idx], prog.Packages[idx+1:]...)
+	delete(prog.pkgIdx, dir)
+	for i := idx; i < len(prog.Packages); i++ {
+		prog.pkgIdx[prog.Packages[i].Dir] = i
+	}
+}
+
+// Package gets a package which read from the directory.
+func (prog *Program) Package(dir string) (*Package, bool) {
+	idx, ok := prog.pkgIdx[dir]
+	if !ok {
+		return nil, false
+	}
+	return prog.Packages[idx], true
+}
+
+// PackageByImportPath gets a package which matches with an import path.
+func (prog *Program) PackageByImportPath(importPath string) (*Package, bool) {
+	for _, pkg := range prog.Packages {
+		if pkg.ImportPath != "" && pkg.ImportPath == importPath {
+			return pkg, true
+		}
+	}
+	return nil, false
+}
+
+// Classify classifies an import path by the module of the program.  Import
+// paths in modules of the workspace are same-module too.  Without go.mod,
+// import paths are classified as stdlib, third-party or cgo.
+func (prog *Program) Classify(importPath string) ImportClass {
+	if prog.Workspace != nil {
+		if _, ok := prog.Workspace.Module(importPath); ok {
+			return ImportSameModule
+		}
+	}
+	if prog.Module != nil {
+		return prog.Module.Classify(importPath)
+	}
+	return classifyPath(importPath)
+}
+
+// Dirs returns sorted directories of packages in the program.
+func (prog *Program) Dirs() []string {
+	return sortedNames(prog.pkgIdx)
+}
+
+// sortPackages sorts packages by directories.
+func (prog *Program) sortPackages() {
+	sort.Slice(prog.Packages, func(i, j int) bool {
+		return prog.Packages[i].Dir < prog.Packages[j].Dir
+	})
+	for i, pkg := range prog.Packages {
+		prog.pkgIdx[pkg.Dir] = i
+	}
+}
@@ -0,0 +1,575 @@
+package srcdom
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Match is a node which matched with a query.
+type Match struct {
+	Node Node
+
+	// Name is a qualified name of the node.  Fields and methods are named
+	// in the form of "{Type}.{Name}".
+	Name string
+
+	Pos token.Position
+}
+
+// Query selects declarations in the package with a query.
+//
+// The query's format is "{kind}" or "{kind} where {condition}".  {kind} is
+// one of "types", "funcs", "methods", "fields", "values", "consts" and
+// "vars".  {condition} is composed of predicates with "and", "or", "not"
+// and parentheses.  Available predicates are:
+//
+//   - {flag}: "public", "struct", "interface", "alias", "method", "const",
+//     "var" and "embedded"
+//   - {prop} {op} {value}: {prop} is one of "name", "type", "recv" and
+//     "value".  {op} is one of "==", "!=" and "=~" (regular expression)
+//   - tag has {value}: {value} is "{tagName}" or "{tagName}:{value}"
+//   - returns {value}: a result type of the func matches
+//   - takes {value}: a parameter type of the func matches
+//
+// {value} is a quoted string or a word.  A predicate can be prefixed by
+// "field.", "method.", "embed.", "param." or "result." to check children
+// of the node.  It is satisfied when some children satisfy it.  For
+// example:
+//
+//	types where struct and field.tag has "db"
+//	funcs where public and returns error
+//	methods where recv == "*Client" and name =~ "^Get"
+func (p *Package) Query(q string) ([]*Match, error) {
+	kind, cond, err := parseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*Match
+	add := func(node Node, name string, pos token.Position) {
+		if cond == nil || cond.eval(node) {
+			matches = append(matches, &Match{Node: node, Name: name, Pos: pos})
+		}
+	}
+	switch kind {
+	case "types":
+		for _, typ := range p.Types {
+			add(typ, typ.Name, typ.Pos)
+		}
+	case "funcs":
+		for _, fn := range p.Funcs {
+			add(fn, fn.Name, fn.Pos)
+		}
+	case "methods":
+		for _, typ := range p.Types {
+			for _, m := range typ.Methods {
+				add(m, typ.Name+"."+m.Name, m.Pos)
+			}
+		}
+	case "fields":
+		for _, typ := range p.Types {
+			// embedded fields are named by their types, like Go does.
+			for _, f := range typ.EmbedFields {
+				add(f, typ.Name+"."+embedBaseName(f.Type), f.Pos)
+			}
+			for _, f := range typ.Fields {
+				add(f, typ.Name+"."+f.Name, f.Pos)
+			}
+		}
+	case "values", "consts", "vars":
+		for _, v := range p.Values {
+			if (kind == "consts" && !v.IsConst) || (kind == "vars" && v.IsConst) {
+				continue
+			}
+			add(v, v.Name, v.Pos)
+		}
+	}
+	return matches, nil
+}
+
+var queryKinds = map[string]bool{
+	"types":   true,
+	"funcs":   true,
+	"methods": true,
+	"fields":  true,
+	"values":  true,
+	"consts":  true,
+	"vars":    true,
+}
+
+// queryCond is a compiled condition of a query.
+type queryCond interface {
+	eval(n Node) bool
+}
+
+type andCond struct{ x, y queryCond }
+
+func (c *andCond) eval(n Node) bool { return c.x.eval(n) && c.y.eval(n) }
+
+type orCond struct{ x, y queryCond }
+
+func (c *orCond) eval(n Node) bool { return c.x.eval(n) || c.y.eval(n) }
+
+type notCond struct{ x queryCond }
+
+func (c *notCond) eval(n Node) bool { return !c.x.eval(n) }
+
+// childCond is satisfied when some children of a node satisfy x.
+type childCond struct {
+	child string
+	x     queryCond
+}
+
+func (c *childCond) eval(n Node) bool {
+	for _, child := range queryChildren(n, c.child) {
+		if c.x.eval(child) {
+			return true
+		}
+	}
+	return false
+}
+
+func queryChildren(n Node, child string) []Node {
+	var nodes []Node
+	switch n := n.(type) {
+	case *Type:
+		switch child {
+		case "field":
+			for _, f := range n.Fields {
+				nodes = append(nodes, f)
+			}
+		case "method":
+			for _, m := range n.Methods {
+				nodes = append(nodes, m)
+			}
+		case "embed":
+			for _, f := range n.EmbedFields {
+				nodes = append(nodes, f)
+			}
+			// embeds of interfaces have no fields.
+			if n.IsInterface {
+				for _, name := range n.Embeds {
+					nodes = append(nodes, &Field{Name: "", Type: name})
+				}
+			}
+		}
+	case *Func:
+		switch child {
+		case "param":
+			for _, v := range n.Params {
+				nodes = append(nodes, v)
+			}
+		case "result":
+			for _, v := range n.Results {
+				nodes = append(nodes, v)
+			}
+		}
+	}
+	return nodes
+}
+
+type flagCond struct{ name string }
+
+func (c *flagCond) eval(n Node) bool {
+	switch n := n.(type) {
+	case *Type:
+		switch c.name {
+		case "public":
+			return n.IsPublic()
+		case "struct":
+			return n.IsStruct
+		case "interface":
+			return n.IsInterface
+		case "alias":
+			return n.Alias
+		}
+	case *Func:
+		switch c.name {
+		case "public":
+			return n.IsPublic()
+		case "method":
+			return n.Recv != ""
+		}
+	case *Field:
+		switch c.name {
+		case "public":
+			return isPublicName(n.Name)
+		case "embedded":
+			return n.Name == ""
+		}
+	case *Value:
+		switch c.name {
+		case "public":
+			return n.IsPublic()
+		case "const":
+			return n.IsConst
+		case "var":
+			return !n.IsConst
+		}
+	}
+	return false
+}
+
+var queryFlags = map[string]bool{
+	"public":    true,
+	"struct":    true,
+	"interface": true,
+	"alias":     true,
+	"method":    true,
+	"const":     true,
+	"var":       true,
+	"embedded":  true,
+}
+
+type compareCond struct {
+	prop  string
+	op    string
+	value string
+	rx    *regexp.Regexp
+}
+
+func (c *compareCond) eval(n Node) bool {
+	v, ok := queryProp(n, c.prop)
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case "==":
+		return v == c.value
+	case "!=":
+		return v != c.value
+	case "=~":
+		return c.rx.MatchString(v)
+	}
+	return false
+}
+
+func queryProp(n Node, prop string) (string, bool) {
+	switch n := n.(type) {
+	case *Type:
+		switch prop {
+		case "name":
+			return n.Name, true
+		case "type":
+			return n.describe(), true
+		}
+	case *Func:
+		switch prop {
+		case "name":
+			return n.Name, true
+		case "recv":
+			return n.Recv, true
+		case "type":
+			return "func" + n.signature(), true
+		}
+	case *Field:
+		switch prop {
+		case "name":
+			return n.Name, true
+		case "type":
+			return n.Type, true
+		}
+	case *Var:
+		switch prop {
+		case "name":
+			return n.Name, true
+		case "type":
+			return n.Type, true
+		}
+	case *Value:
+		switch prop {
+		case "name":
+			return n.Name, true
+		case "type":
+			return n.TypeExpr, true
+		case "value":
+			if n.Literal == nil {
+				return "", false
+			}
+			return n.Literal.Value, true
+		}
+	}
+	return "", false
+}
+
+var queryProps = map[string]bool{
+	"name":  true,
+	"type":  true,
+	"recv":  true,
+	"value": true,
+}
+
+type tagCond struct {
+	name  string
+	value *string
+}
+
+func (c *tagCond) eval(n Node) bool {
+	f, ok := n.(*Field)
+	if !ok || f.Tag == nil {
+		return false
+	}
+	return f.Tag.match(c.name, c.value)
+}
+
+// parseTagQuery parses a tag query: "{tagName}" or "{tagName}:{value}".
+func parseTagQuery(q string) (name string, value *string) {
+	name, v, ok := strings.Cut(q, ":")
+	if !ok {
+		return name, nil
+	}
+	return name, &v
+}
+
+// queryToken is a token of a query.
+type queryToken struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeQuery(q string) ([]queryToken, error) {
+	var tokens []queryToken
+	rs := []rune(q)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, queryToken{text: string(r)})
+			i++
+		case r == '"' || r == '`':
+			j := i + 1
+			for j < len(rs) && rs[j] != r {
+				if rs[j] == '\\' && r == '"' {
+					j++
+				}
+				j++
+			}
+			if j >= len(rs) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			s, err := strconv.Unquote(string(rs[i : j+1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d: %w", i, err)
+			}
+			tokens = append(tokens, queryToken{text: s, quoted: true})
+			i = j + 1
+		case strings.ContainsRune("=!", r):
+			if i+1 < len(rs) && strings.ContainsRune("=~", rs[i+1]) {
+				op := string(rs[i : i+2])
+				if op == "==" || op == "!=" || op == "=~" {
+					tokens = append(tokens, queryToken{text: op})
+					i += 2
+					continue
+				}
+			}
+			return nil, fmt.Errorf("unknown operator at %d", i)
+		default:
+			j := i
+			for j < len(rs) && !unicode.IsSpace(rs[j]) && !strings.ContainsRune(`()"=!`+"`", rs[j]) {
+				j++
+			}
+			tokens = append(tokens, queryToken{text: string(rs[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (qp *queryParser) peek() (queryToken, bool) {
+	if qp.pos >= len(qp.tokens) {
+		return queryToken{}, false
+	}
+	return qp.tokens[qp.pos], true
+}
+
+// keyword checks the next token is an unquoted word s, and consumes it.
+func (qp *queryParser) keyword(s string) bool {
+	t, ok := qp.peek()
+	if !ok || t.quoted || t.text != s {
+		return false
+	}
+	qp.pos++
+	return true
+}
+
+func (qp *queryParser) next() (queryToken, error) {
+	t, ok := qp.peek()
+	if !ok {
+		return queryToken{}, fmt.Errorf("unexpected end of query")
+	}
+	qp.pos++
+	return t, nil
+}
+
+func parseQuery(q string) (string, queryCond, error) {
+	tokens, err := tokenizeQuery(q)
+	if err != nil {
+		return "", nil, err
+	}
+	qp := &queryParser{tokens: tokens}
+	kind, err := qp.next()
+	if err != nil {
+		return "", nil, err
+	}
+	if kind.quoted || !queryKinds[kind.text] {
+		return "", nil, fmt.Errorf("unknown kind: %q", kind.text)
+	}
+	if _, ok := qp.peek(); !ok {
+		return kind.text, nil, nil
+	}
+	if !qp.keyword("where") {
+		t, _ := qp.peek()
+		return "", nil, fmt.Errorf("\"where\" expected but got %q", t.text)
+	}
+	cond, err := qp.parseOr()
+	if err != nil {
+		return "", nil, err
+	}
+	if t, ok := qp.peek(); ok {
+		return "", nil, fmt.Errorf("unexpected token: %q", t.text)
+	}
+	return kind.text, cond, nil
+}
+
+func (qp *queryParser) parseOr() (queryCond, error) {
+	x, err := qp.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for qp.keyword("or") {
+		y, err := qp.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &orCond{x: x, y: y}
+	}
+	return x, nil
+}
+
+func (qp *queryParser) parseAnd() (queryCond, error) {
+	x, err := qp.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for qp.keyword("and") {
+		y, err := qp.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		x = &andCond{x: x, y: y}
+	}
+	return x, nil
+}
+
+func (qp *queryParser) parseNot() (queryCond, error) {
+	if qp.keyword("not") {
+		x, err := qp.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notCond{x: x}, nil
+	}
+	return qp.parsePrimary()
+}
+
+func (qp *queryParser) parsePrimary() (queryCond, error) {
+	if qp.keyword("(") {
+		x, err := qp.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !qp.keyword(")") {
+			return nil, fmt.Errorf("\")\" expected")
+		}
+		return x, nil
+	}
+	t, err := qp.next()
+	if err != nil {
+		return nil, err
+	}
+	if t.quoted {
+		return nil, fmt.Errorf("unexpected string: %q", t.text)
+	}
+	name := t.text
+	var children []string
+	for {
+		child, rest, ok := strings.Cut(name, ".")
+		if !ok {
+			break
+		}
+		switch child {
+		case "field", "method", "embed", "param", "result":
+		default:
+			return nil, fmt.Errorf("unknown children: %q", child)
+		}
+		children = append(children, child)
+		name = rest
+	}
+	cond, err := qp.parsePredicate(name)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(children) - 1; i >= 0; i-- {
+		cond = &childCond{child: children[i], x: cond}
+	}
+	return cond, nil
+}
+
+func (qp *queryParser) parsePredicate(name string) (queryCond, error) {
+	switch {
+	case name == "tag":
+		if !qp.keyword("has") {
+			return nil, fmt.Errorf("\"has\" expected after \"tag\"")
+		}
+		v, err := qp.next()
+		if err != nil {
+			return nil, err
+		}
+		tagName, tagValue := parseTagQuery(v.text)
+		return &tagCond{name: tagName, value: tagValue}, nil
+	case name == "returns" || name == "takes":
+		v, err := qp.next()
+		if err != nil {
+			return nil, err
+		}
+		child := "result"
+		if name == "takes" {
+			child = "param"
+		}
+		return &childCond{child: child, x: &compareCond{prop: "type", op: "==", value: v.text}}, nil
+	case queryFlags[name]:
+		return &flagCond{name: name}, nil
+	case queryProps[name]:
+		op, err := qp.next()
+		if err != nil {
+			return nil, err
+		}
+		if op.quoted || (op.text != "==" && op.text != "!=" && op.text != "=~") {
+			return nil, fmt.Errorf("operator expected after %q but got %q", name, op.text)
+		}
+		v, err := qp.next()
+		if err != nil {
+			return nil, err
+		}
+		c := &compareCond{prop: name, op: op.text, value: v.text}
+		if c.op == "=~" {
+			c.rx, err = regexp.Compile(c.value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return c, nil
+	default:
+		return nil, fmt.Errorf("unknown predicate: %q", name)
+	}
+}
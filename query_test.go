@@ -0,0 +1,122 @@
+package srcdom_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+const querySource = `package foo
+
+type User struct {
+	ID   int    ` + "`db:\"id\" json:\"id\"`" + `
+	Name string ` + "`json:\"name\" validate:\"required max\"`" + `
+}
+
+func (u *User) Validate() error { return nil }
+
+func (u *User) String() string { return "" }
+
+type Item struct {
+	*Base
+	Price int ` + "`db:\"price\"`" + `
+}
+
+type Store interface {
+	Get(id int) (*User, error)
+}
+
+func NewUser(name string) (*User, error) { return nil, nil }
+
+func helper() error { return nil }
+
+func Print(u *User) {}
+
+const Version = "1.0"
+
+var Debug bool
+`
+
+func TestQuery(t *testing.T) {
+	pkg, err := srcdom.ReadSource("foo.go", []byte(querySource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		query string
+		want  []string
+	}{
+		{`types`, []string{"User", "Item", "Store"}},
+		{`types where struct and field.tag has "db"`, []string{"User", "Item"}},
+		{`types where field.tag has "validate:required"`, []string{"User"}},
+		{`types where interface or method.name == String`, []string{"User", "Store"}},
+		{`funcs where public and returns error`, []string{"NewUser"}},
+		{`funcs where takes "*User" or returns "*User"`, []string{"NewUser", "Print"}},
+		{`funcs where not public`, []string{"helper"}},
+		{`methods where recv == "*User" and name =~ "^V"`, []string{"User.Validate"}},
+		{`methods where (returns error) and not method`, []string{"Store.Get"}},
+		{`fields where tag has db and not (type == string)`, []string{"User.ID", "Item.Price"}},
+		{`fields where embedded`, []string{"Item.Base"}},
+		{`types where embed.type == "*Base"`, []string{"Item"}},
+		{`consts`, []string{"Version"}},
+		{`values where var or value == "\"1.0\""`, []string{"Version", "Debug"}},
+	} {
+		matches, err := pkg.Query(c.query)
+		if err != nil {
+			t.Errorf("query %q failed: %s", c.query, err)
+			continue
+		}
+		var got []string
+		for _, m := range matches {
+			got = append(got, m.Name)
+			if !m.Pos.IsValid() {
+				t.Errorf("query %q: no position for %s", c.query, m.Name)
+			}
+		}
+		if d := cmp.Diff(c.want, got); d != "" {
+			t.Errorf("query %q unmatch: -want +got\n%s", c.query, d)
+		}
+	}
+
+	for _, q := range []string{
+		``,
+		`classes`,
+		`types when struct`,
+		`types where`,
+		`types where unknown`,
+		`types where name`,
+		`types where name = "x"`,
+		`types where (struct`,
+		`types where struct struct`,
+		`types where foo.name == x`,
+		`types where name =~ "("`,
+		`types where name == "x`,
+	} {
+		if _, err := pkg.Query(q); err == nil {
+			t.Errorf("query %q should fail", q)
+		}
+	}
+}
+
+func TestFieldsByTag(t *testing.T) {
+	pkg, err := srcdom.ReadSource("foo.go", []byte(querySource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ, _ := pkg.Type("User")
+	for q, want := range map[string][]string{
+		"json":         {"ID", "Name"},
+		"db":           {"ID"},
+		"validate:max": {"Name"},
+		"xml":          nil,
+	} {
+		var got []string
+		for _, f := range typ.FieldsByTag(q) {
+			got = append(got, f.Name)
+		}
+		if d := cmp.Diff(want, got); d != "" {
+			t.Errorf("FieldsByTag(%q) unmatch: -want +got\n%s", q, d)
+		}
+	}
+}
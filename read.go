@@ -7,28 +7,133 @@ import (
 	"go/build/constraint"
 	"go/parser"
 	"go/token"
-	"log"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
 	"sort"
+	"strings"
 )
 
+// Config is a configuration to read packages.
+type Config struct {
+	// TypeCheck enables type-checking with go/types after reading sources.
+	// Results are attached to Package and each element as types.Object.
+	// When type-checking fails, the Package is still built from syntax,
+	// and errors are recorded in Package.TypeErrors.
+	TypeCheck bool
+
+	// Tolerant enables error-tolerant reading.  Parse errors and
+	// unsupported constructs don't abort reading, but are collected into
+	// Package.Diagnostics.
+	Tolerant bool
+
+	// Handler receives diagnostics while reading, when it is set.
+	// Regardless of this, all diagnostics are recorded in
+	// Package.Diagnostics.
+	Handler DiagnosticHandler
+
+	// ScanBodies enables to collect references from function bodies to
+	// package-level declarations.  See Func.Refs.
+	ScanBodies bool
+
+	// ResolveEmbeds enables to list files for "//go:embed" variables, when
+	// reading directories.  See Embed.Files.
+	ResolveEmbeds bool
+
+	// SkipGenerated makes to skip generated files, which have a
+	// "// Code generated ... DO NOT EDIT." comment.
+	SkipGenerated bool
+}
+
+func (c *Config) typeCheck() bool {
+	return c != nil && c.TypeCheck
+}
+
+func (c *Config) tolerant() bool {
+	return c != nil && c.Tolerant
+}
+
+func (c *Config) handler() DiagnosticHandler {
+	if c == nil {
+		return nil
+	}
+	return c.Handler
+}
+
+func (c *Config) skipGenerated() bool {
+	return c != nil && c.SkipGenerated
+}
+
+func (c *Config) parseMode() parser.Mode {
+	mode := parser.ParseComments
+	if c.tolerant() {
+		mode |= parser.AllErrors
+	}
+	return mode
+}
+
+func (c *Config) newParser(fset *token.FileSet) *Parser {
+	p := &Parser{Fset: fset}
+	if c != nil {
+		p.Tolerant = c.Tolerant
+		p.Handler = c.Handler
+		p.ScanBodies = c.ScanBodies
+	}
+	return p
+}
+
+// parseFile parses a file.  In tolerant mode, it returns a partial AST as
+// long as possible, and parse errors are reported as diagnostics.  The AST
+// may be nil when the file is not a Go source at all.
+func (c *Config) parseFile(p *Parser, name string, src any) (*ast.File, error) {
+	file, err := parser.ParseFile(p.Fset, name, src, c.parseMode())
+	if err == nil {
+		return file, nil
+	}
+	if !c.tolerant() {
+		return nil, err
+	}
+	for _, d := range toDiagnostics(err) {
+		p.report(d)
+	}
+	if file != nil && (file.Name == nil || file.Name.Name == "") {
+		return nil, nil
+	}
+	return file, nil
+}
+
 // readFile reads a file as a Package.
-func readFile(name string) (*Package, error) {
+func readFile(cfg *Config, name string) (*Package, error) {
 	f, err := os.Open(name)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	return readSource(cfg, name, f)
+}
+
+// readSource reads a source as a Package.  See parser.ParseFile for types
+// of src.
+func readSource(cfg *Config, name string, src any) (*Package, error) {
 	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, name, f, 0)
+	p := cfg.newParser(fset)
+	file, err := cfg.parseFile(p, name, src)
 	if err != nil {
 		return nil, err
 	}
-	p := &Parser{}
+	if file == nil || cfg.skipGenerated() && ast.IsGenerated(file) {
+		return &Package{Diagnostics: p.Diagnostics}, nil
+	}
 	err = p.ScanFile(file)
 	if err != nil {
 		return nil, err
 	}
+	p.Package.Diagnostics = p.Diagnostics
+	p.Package.ResolveRefs()
+	if cfg.typeCheck() {
+		checkTypes(p.Package, fset, []*ast.File{file})
+	}
 	return p.Package, nil
 }
 
@@ -43,14 +148,101 @@ func sortFileNames(src map[string]*ast.File) []string {
 	return names
 }
 
-func toPackages(pkgMap map[string]*ast.Package) []*ast.Package {
-	pkgs := make([]*ast.Package, 0, len(pkgMap))
+// astPackage is a set of files which share a package name.
+type astPackage struct {
+	Name  string
+	Files map[string]*ast.File
+
+	// Excluded are files which excluded by build constraints.
+	Excluded map[string]*ast.File
+}
+
+// exclude moves a file to Excluded.
+func (pkg *astPackage) exclude(name string) {
+	if pkg.Excluded == nil {
+		pkg.Excluded = map[string]*ast.File{}
+	}
+	pkg.Excluded[name] = pkg.Files[name]
+	delete(pkg.Files, name)
+}
+
+func toPackages(pkgMap map[string]*astPackage) []*astPackage {
+	pkgs := make([]*astPackage, 0, len(pkgMap))
 	for _, p := range pkgMap {
 		pkgs = append(pkgs, p)
 	}
 	return pkgs
 }
 
+// dirSource is a directory which contains source files of a package.
+type dirSource struct {
+	fsys fs.FS
+	dir  string
+
+	// base is a directory name in the OS filesystem, which corresponds to
+	// dir in fsys.  When it is not empty, it is used to name files.
+	base string
+}
+
+func osDirSource(path string) dirSource {
+	return dirSource{fsys: os.DirFS(path), dir: ".", base: path}
+}
+
+// String returns the name of the directory.
+func (d dirSource) String() string {
+	if d.base != "" {
+		return d.base
+	}
+	return d.dir
+}
+
+// filename returns a name for a file in the directory.
+func (d dirSource) filename(name string) string {
+	if d.base != "" {
+		return filepath.Join(d.base, filepath.FromSlash(name))
+	}
+	return path.Join(d.dir, name)
+}
+
+func (d dirSource) readFile(name string) ([]byte, error) {
+	return fs.ReadFile(d.fsys, path.Join(d.dir, name))
+}
+
+// parseDir parses all ".go" files in a directory, and groups them by
+// package names.
+func (c *Config) parseDir(p *Parser, src dirSource) (map[string]*astPackage, error) {
+	entries, err := fs.ReadDir(src.fsys, src.dir)
+	if err != nil {
+		return nil, err
+	}
+	pkgMap := map[string]*astPackage{}
+	for _, d := range entries {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".go") {
+			continue
+		}
+		b, err := src.readFile(d.Name())
+		if err != nil {
+			return nil, err
+		}
+		name := src.filename(d.Name())
+		file, err := c.parseFile(p, name, b)
+		if err != nil {
+			return nil, err
+		}
+		if file == nil {
+			continue
+		}
+		pname := file.Name.Name
+		pkg, ok := pkgMap[pname]
+		if !ok {
+			pkg = &astPackage{Name: pname, Files: map[string]*ast.File{}}
+			pkgMap[pname] = pkg
+		}
+		pkg.Files[name] = file
+	}
+	return pkgMap, nil
+}
+
 func joinExprListWithOrExpr(list []constraint.Expr) constraint.Expr {
 	if len(list) == 1 {
 		return list[0]
@@ -84,29 +276,89 @@ func extractBuildDirectives(file *ast.File) (constraint.Expr, error) {
 	return joinExprListWithOrExpr(plusBuilds), nil
 }
 
-var debugFilterdPackage bool = true
-
 // readDir reads all files in a directory as a Package.
-func readDir(path string, testPackage bool, tags map[string]bool) (*Package, error) {
+func readDir(cfg *Config, src dirSource, testPackage bool, tags map[string]bool) (*Package, error) {
 	fset := token.NewFileSet()
-	pkgMap, err := parser.ParseDir(fset, path, nil, parser.ParseComments)
+	p := cfg.newParser(fset)
+	pkg, xtest, err := cfg.parseDirPackages(p, src, tags)
 	if err != nil {
 		return nil, err
 	}
+	if pkg == nil {
+		return &Package{Diagnostics: p.Diagnostics}, nil
+	}
+	// use test package.
+	if testPackage && xtest != nil {
+		pkg = xtest
+	}
+	return cfg.scanPackage(p, src, pkg)
+}
+
+// readPackageSet reads all files in a directory as a PackageSet.
+func readPackageSet(cfg *Config, src dirSource, tags map[string]bool) (*PackageSet, error) {
+	fset := token.NewFileSet()
+	p := cfg.newParser(fset)
+	pkg, xtest, err := cfg.parseDirPackages(p, src, tags)
+	if err != nil {
+		return nil, err
+	}
+	if pkg == nil {
+		return &PackageSet{Package: &Package{Diagnostics: p.Diagnostics}}, nil
+	}
+	set := &PackageSet{}
+	if xtest != nil {
+		set.XTest, err = cfg.scanPackage(cfg.newParser(fset), src, xtest)
+		if err != nil {
+			return nil, err
+		}
+	}
+	set.Package, err = cfg.scanPackage(p, src, pkg)
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// parseDirPackages parses files in a directory and filters those by build
+// tags.  It returns the package and the external test package.  Both are
+// nil when no files are left.
+func (c *Config) parseDirPackages(p *Parser, src dirSource, tags map[string]bool) (pkg, xtest *astPackage, err error) {
+	pkgMap, err := c.parseDir(p, src)
+	if err != nil {
+		return nil, nil, err
+	}
 	var filtered bool
 	// remove packages which have no files to be built.
 	for pname, pkg := range pkgMap {
 		// filter pkg.Files by build tags
 		for fname, file := range pkg.Files {
+			if c.skipGenerated() && ast.IsGenerated(file) {
+				delete(pkg.Files, fname)
+				continue
+			}
 			expr, err := extractBuildDirectives(file)
 			if err != nil {
-				return nil, err
+				if !c.tolerant() {
+					return nil, nil, err
+				}
+				p.report(&Diagnostic{
+					Pos:      p.Fset.Position(file.Package),
+					Severity: SeverityError,
+					Code:     CodeBuildConstraint,
+					Message:  err.Error(),
+				})
+				continue
+			}
+			// files which import "C" are built only when cgo is enabled.
+			if spec, _ := cgoImport(file); spec != nil && !tags["cgo"] {
+				pkg.exclude(fname)
+				continue
 			}
 			if expr == nil {
 				continue
 			}
 			if !expr.Eval(func(tag string) bool { return tags[tag] }) {
-				delete(pkg.Files, fname)
+				pkg.exclude(fname)
 			}
 		}
 		if len(pkg.Files) == 0 {
@@ -115,37 +367,68 @@ func readDir(path string, testPackage bool, tags map[string]bool) (*Package, err
 		}
 	}
 	if len(pkgMap) == 0 {
-		if debugFilterdPackage && filtered {
-			log.Printf("package:%s is empty because filtered\n", path)
+		if filtered {
+			p.report(&Diagnostic{
+				Pos:      token.Position{Filename: src.String()},
+				Severity: SeverityInfo,
+				Code:     CodeFilteredPackage,
+				Message:  fmt.Sprintf("package:%s is empty because filtered", src),
+			})
 		}
-		return &Package{}, nil
+		return nil, nil, nil
 	}
 	if len(pkgMap) > 2 {
-		return nil, fmt.Errorf("multiple packages in directory %s", path)
+		return nil, nil, fmt.Errorf("multiple packages in directory %s", src)
 	}
 	pkgs := toPackages(pkgMap)
 	// check pkgs includes only target and test packages.
-	pkg := pkgs[0]
+	pkg = pkgs[0]
 	if len(pkgs) == 2 {
-		testPkg := pkgs[1]
-		if len(pkg.Name) > len(testPkg.Name) {
-			pkg, testPkg = testPkg, pkg
+		xtest = pkgs[1]
+		if len(pkg.Name) > len(xtest.Name) {
+			pkg, xtest = xtest, pkg
 		}
-		if pkg.Name+"_test" != testPkg.Name {
-			return nil, fmt.Errorf("multiple non-test packages in directory %s: %s, %s", path, pkg.Name, testPkg.Name)
-		}
-		// use test package.
-		if testPackage {
-			pkg = testPkg
+		if pkg.Name+"_test" != xtest.Name {
+			return nil, nil, fmt.Errorf("multiple non-test packages in directory %s: %s, %s", src, pkg.Name, xtest.Name)
 		}
 	}
-	p := &Parser{}
-	for _, n := range sortFileNames(pkg.Files) {
+	return pkg, xtest, nil
+}
+
+// scanPackage scans all files of an ast package to build a Package.
+func (c *Config) scanPackage(p *Parser, src dirSource, pkg *astPackage) (*Package, error) {
+	names := sortFileNames(pkg.Files)
+	files := make([]*ast.File, 0, len(names))
+	for _, n := range names {
 		file := pkg.Files[n]
 		err := p.ScanFile(file)
 		if err != nil {
 			return nil, err
 		}
+		files = append(files, file)
+	}
+	for _, n := range sortFileNames(pkg.Excluded) {
+		p.Package.ConstrainedImports = append(p.Package.ConstrainedImports, p.fileImports(pkg.Excluded[n])...)
+	}
+	p.Package.Dir = src.String()
+	if m, rel, err := findModule(src); err != nil {
+		p.report(&Diagnostic{
+			Pos:      token.Position{Filename: src.String()},
+			Severity: SeverityWarning,
+			Code:     CodeModule,
+			Message:  err.Error(),
+		})
+	} else if m != nil {
+		p.Package.ImportPath = m.importPath(rel)
+		p.Package.ModuleGoVersion = m.GoVersion
+	}
+	if c != nil && c.ResolveEmbeds {
+		c.resolveEmbeds(p, src)
+	}
+	p.Package.Diagnostics = p.Diagnostics
+	p.Package.ResolveRefs()
+	if c.typeCheck() {
+		checkTypes(p.Package, p.Fset, files)
 	}
 	return p.Package, nil
 }
@@ -154,6 +437,9 @@ func getTags() map[string]bool {
 	tagMap := map[string]bool{}
 	tagMap[build.Default.GOARCH] = true
 	tagMap[build.Default.GOOS] = true
+	if cgoEnabled() {
+		tagMap["cgo"] = true
+	}
 	for _, tags := range [][]string{build.Default.BuildTags, build.Default.ToolTags, build.Default.ReleaseTags} {
 		for _, tag := range tags {
 			tagMap[tag] = true
@@ -162,24 +448,22 @@ func getTags() map[string]bool {
 	return tagMap
 }
 
-// Read reads a file or directory as a Package.
-// If you are going to read a directory, see also ReadDir.
-func Read(path string) (*Package, error) {
+// Read reads a file or directory as a Package with the configuration.
+func (c *Config) Read(path string) (*Package, error) {
 	fi, err := os.Stat(path)
 	if err != nil {
 		return nil, err
 	}
 	if fi.IsDir() {
 		tags := getTags()
-		return readDir(path, false, tags)
+		return readDir(c, osDirSource(path), false, tags)
 	}
-	return readFile(path)
+	return readFile(c, path)
 }
 
-// ReadDir reads a directory as a Package.  It reads "test" package when
-// `testPackage` is set.  It will fail if the directory contains non-test
-// multiple packages.
-func ReadDir(path string, testPackage bool) (*Package, error) {
+// ReadDir reads a directory as a Package with the configuration.
+// See also ReadDir function.
+func (c *Config) ReadDir(path string, testPackage bool) (*Package, error) {
 	fi, err := os.Stat(path)
 	if err != nil {
 		return nil, err
@@ -188,5 +472,70 @@ func ReadDir(path string, testPackage bool) (*Package, error) {
 		return nil, fmt.Errorf("path is not a directory: %q", path)
 	}
 	tags := getTags()
-	return readDir(path, testPackage, tags)
+	return readDir(c, osDirSource(path), testPackage, tags)
+}
+
+// ReadPackageSet reads a directory as a PackageSet with the configuration.
+// See also ReadPackageSet function.
+func (c *Config) ReadPackageSet(path string) (*PackageSet, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("path is not a directory: %q", path)
+	}
+	tags := getTags()
+	return readPackageSet(c, osDirSource(path), tags)
+}
+
+// ReadFS reads a directory in fsys as a Package with the configuration.
+// dir is a slash separated path in fsys.  It reads "test" package when
+// `testPackage` is set.
+func (c *Config) ReadFS(fsys fs.FS, dir string, testPackage bool) (*Package, error) {
+	fi, err := fs.Stat(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("path is not a directory: %q", dir)
+	}
+	tags := getTags()
+	return readDir(c, dirSource{fsys: fsys, dir: dir}, testPackage, tags)
+}
+
+// ReadSource reads a source in memory as a Package with the configuration.
+// filename is used to name positions.
+func (c *Config) ReadSource(filename string, src []byte) (*Package, error) {
+	return readSource(c, filename, src)
+}
+
+// Read reads a file or directory as a Package.
+// If you are going to read a directory, see also ReadDir.
+func Read(path string) (*Package, error) {
+	return (&Config{}).Read(path)
+}
+
+// ReadDir reads a directory as a Package.  It reads "test" package when
+// `testPackage` is set.  It will fail if the directory contains non-test
+// multiple packages.
+func ReadDir(path string, testPackage bool) (*Package, error) {
+	return (&Config{}).ReadDir(path, testPackage)
+}
+
+// ReadPackageSet reads a directory as a PackageSet, which holds both the
+// package and the external test package.  Files are parsed only once.
+func ReadPackageSet(path string) (*PackageSet, error) {
+	return (&Config{}).ReadPackageSet(path)
+}
+
+// ReadFS reads a directory in fsys as a Package.  dir is a slash separated
+// path in fsys.  It reads "test" package when `testPackage` is set.
+func ReadFS(fsys fs.FS, dir string, testPackage bool) (*Package, error) {
+	return (&Config{}).ReadFS(fsys, dir, testPackage)
+}
+
+// ReadSource reads a source in memory as a Package.
+func ReadSource(filename string, src []byte) (*Package, error) {
+	return (&Config{}).ReadSource(filename, src)
 }
@@ -1,10 +1,12 @@
 package srcdom_test
 
 import (
+	"go/token"
 	"io/fs"
 	"path/filepath"
 	"runtime"
 	"testing"
+	"testing/fstest"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -54,10 +56,14 @@ func TestReadGOROOT(t *testing.T) {
 }
 
 func TestReadFile(t *testing.T) {
-	got, err := srcdom.Read(filepath.Join("_testdata", "test1.go"))
+	name := filepath.Join("_testdata", "test1.go")
+	got, err := srcdom.Read(name)
 	if err != nil {
 		t.Fatal(err)
 	}
+	pos := func(offset, line, column int) token.Position {
+		return token.Position{Filename: name, Offset: offset, Line: line, Column: column}
+	}
 	want := srcdom.Package{
 		Name: "testdata",
 		Funcs: []*srcdom.Func{
@@ -65,13 +71,14 @@ func TestReadFile(t *testing.T) {
 				Name:    "Func1",
 				Params:  []*srcdom.Var{{Name: "arg1", Type: "string"}},
 				Results: []*srcdom.Var{{Type: "error"}},
+				Pos:     pos(23, 3, 6),
 			},
-			{Name: "Func0"},
+			{Name: "Func0", Pos: pos(97, 6, 6), Doc: "Func0 has no parameters.\n"},
 		},
 		Values: []*srcdom.Value{
-			{Name: "VarFoo", Type: "int"},
-			{Name: "VarBar", Type: "string"},
-			{Name: "varPriv", Type: "float64"},
+			{Name: "VarFoo", Type: "int", TypeExpr: "int", Pos: pos(116, 9, 2), DeclPos: pos(109, 8, 1)},
+			{Name: "VarBar", Type: "string", TypeExpr: "string", Pos: pos(129, 10, 2), DeclPos: pos(109, 8, 1)},
+			{Name: "varPriv", Type: "float64", TypeExpr: "float64", Pos: pos(145, 11, 2), DeclPos: pos(109, 8, 1)},
 		},
 	}
 	if d := cmp.Diff(&want, got, cmpopts.IgnoreUnexported(srcdom.Package{})); d != "" {
@@ -85,9 +92,9 @@ func TestReadFile(t *testing.T) {
 			ispub bool
 			want  srcdom.Value
 		}{
-			{"VarFoo", true, srcdom.Value{Name: "VarFoo", Type: "int"}},
-			{"VarBar", true, srcdom.Value{Name: "VarBar", Type: "string"}},
-			{"varPriv", false, srcdom.Value{Name: "varPriv", Type: "float64"}},
+			{"VarFoo", true, srcdom.Value{Name: "VarFoo", Type: "int", TypeExpr: "int", Pos: pos(116, 9, 2), DeclPos: pos(109, 8, 1)}},
+			{"VarBar", true, srcdom.Value{Name: "VarBar", Type: "string", TypeExpr: "string", Pos: pos(129, 10, 2), DeclPos: pos(109, 8, 1)}},
+			{"varPriv", false, srcdom.Value{Name: "varPriv", Type: "float64", TypeExpr: "float64", Pos: pos(145, 11, 2), DeclPos: pos(109, 8, 1)}},
 		} {
 			got, ok := pkg.Value(c.name)
 			if !ok {
@@ -126,8 +133,9 @@ func TestReadFile(t *testing.T) {
 				Name:    "Func1",
 				Params:  []*srcdom.Var{{Name: "arg1", Type: "string"}},
 				Results: []*srcdom.Var{{Type: "error"}},
+				Pos:     pos(23, 3, 6),
 			}},
-			{"Func0", true, srcdom.Func{Name: "Func0"}},
+			{"Func0", true, srcdom.Func{Name: "Func0", Pos: pos(97, 6, 6), Doc: "Func0 has no parameters.\n"}},
 		} {
 			got, ok := pkg.Func(c.name)
 			if !ok {
@@ -155,3 +163,123 @@ func TestReadFile(t *testing.T) {
 		}
 	})
 }
+
+func TestReadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo/foo.go":      {Data: []byte("package foo\n\nfunc Foo() {}\n")},
+		"foo/foo_test.go": {Data: []byte("package foo_test\n\nfunc TestFoo() {}\n")},
+		"foo/README.md":   {Data: []byte("# foo\n")},
+	}
+	p, err := srcdom.ReadFS(fsys, "foo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "foo" {
+		t.Errorf("unexpected package name: want=%s got=%s", "foo", p.Name)
+	}
+	if d := cmp.Diff([]string{"Foo"}, p.FuncNames()); d != "" {
+		t.Errorf("unmatch FuncNames() result: -want +got\n%s", d)
+	}
+
+	p2, err := (&srcdom.Config{}).ReadFS(fsys, "foo", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p2.Name != "foo_test" {
+		t.Errorf("unexpected package name: want=%s got=%s", "foo_test", p2.Name)
+	}
+
+	if _, err := srcdom.ReadFS(fsys, "foo/foo.go", false); err == nil {
+		t.Error("ReadFS should fail for a file")
+	}
+}
+
+func TestReadSource(t *testing.T) {
+	src := []byte("package bar\n\nconst Answer = 42\n\nfunc Bar(s string) error { return nil }\n")
+	p, err := srcdom.ReadSource("bar.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "bar" {
+		t.Errorf("unexpected package name: want=%s got=%s", "bar", p.Name)
+	}
+	v, ok := p.Value("Answer")
+	if !ok || !v.IsConst || v.Literal == nil || v.Literal.Value != "42" {
+		t.Errorf("unexpected value Answer: %+v", v)
+	}
+	if _, ok := p.Func("Bar"); !ok {
+		t.Error("func Bar not found")
+	}
+}
+
+func TestReadValueTypes(t *testing.T) {
+	pkg, err := srcdom.ReadSource("foo.go", []byte(`package foo
+
+type Kind int
+
+const (
+	A Kind = iota
+	B
+	C = 10
+	D
+)
+
+var (
+	x Kind
+	y = 1
+)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"A": "Kind",
+		"B": "Kind",
+		"C": "",
+		"D": "",
+		"x": "Kind",
+		"y": "",
+	} {
+		v, _ := pkg.Value(name)
+		if v.Type != want {
+			t.Errorf("unexpected type of %s: want=%q got=%q", name, want, v.Type)
+		}
+	}
+}
+
+func TestReadStructFields(t *testing.T) {
+	pkg, err := srcdom.ReadSource("foo.go", []byte(`package foo
+
+import "io"
+
+type Foo struct {
+	io.Reader
+	*Bar
+	a, b int `+"`json:\"ab\"`"+`
+	c    string
+}
+
+type Bar struct{}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ, ok := pkg.Type("Foo")
+	if !ok {
+		t.Fatal("Foo not found")
+	}
+	if d := cmp.Diff([]string{"io.Reader", "*Bar"}, typ.Embeds); d != "" {
+		t.Errorf("unexpected embeds: -want +got\n%s", d)
+	}
+	var got []string
+	for _, f := range typ.Fields {
+		got = append(got, f.Name+" "+f.Type+" "+f.Tag.Raw)
+	}
+	if d := cmp.Diff([]string{
+		"a int json:\"ab\"",
+		"b int json:\"ab\"",
+		"c string ",
+	}, got); d != "" {
+		t.Errorf("unexpected fields: -want +got\n%s", d)
+	}
+}
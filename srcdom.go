@@ -7,6 +7,8 @@ package srcdom
 
 import (
 	"go/ast"
+	"go/token"
+	"go/types"
 	"regexp"
 	"sort"
 	"strconv"
@@ -36,8 +38,29 @@ func sortedNames(m map[string]int) []string {
 type Package struct {
 	Name string
 
+	// Doc is the package comment, without comment markers.  Comments in
+	// multiple files except "_test.go" files are joined.
+	Doc string
+
+	// Dir is a directory which the package was read from.  It is empty
+	// when the package was read from a file.
+	Dir string
+
+	// ImportPath is the import path of the package, which determined by
+	// go.mod of the module.  It is empty when go.mod is not found.
+	ImportPath string
+
+	// ModuleGoVersion is the Go version in go.mod of the module, which
+	// contains Dir, like "go1.21".  It is empty when not found.
+	ModuleGoVersion string
+
 	Imports []*Import
 
+	// ConstrainedImports are imports in files which excluded by build
+	// constraints, like files for other platforms.  Those are collected
+	// only when reading directories.
+	ConstrainedImports []*Import
+
 	Values []*Value
 	valIdx map[string]int
 
@@ -46,6 +69,24 @@ type Package struct {
 
 	Types  []*Type
 	typIdx map[string]int
+
+	files   []*File
+	fileIdx map[string]int
+
+	// settings are settings of the parser which scanned the package.
+	settings parserSettings
+
+	// TypesPackage and TypesInfo are results of type-checking.  These are
+	// available only when Config.TypeCheck is enabled.
+	TypesPackage *types.Package
+	TypesInfo    *types.Info
+
+	// TypeErrors holds errors which occurred while type-checking.
+	TypeErrors []error
+
+	// Diagnostics holds problems which found while reading the package in
+	// tolerant mode.
+	Diagnostics []*Diagnostic
 }
 
 func (p *Package) putValue(v *Value) {
@@ -130,12 +171,17 @@ func (p *Package) TypeNames() []string {
 type Import struct {
 	Name string
 	Path string
+
+	Pos token.Position
 }
 
 // Var represents a variable.
 type Var struct {
 	Name string
 	Type string
+
+	// Obj is a type-checked object, available with Config.TypeCheck.
+	Obj types.Object
 }
 
 // Field represents a variable.
@@ -143,6 +189,18 @@ type Field struct {
 	Name string
 	Type string
 	Tag  *Tag
+
+	Pos token.Position
+
+	// Doc is the doc comment, or the line comment when the doc comment is
+	// missing.
+	Doc string
+
+	// Directives are directives in the doc comment and the line comment.
+	Directives []*Directive
+
+	// Obj is a type-checked object, available with Config.TypeCheck.
+	Obj types.Object
 }
 
 // Tag represents a tag for field
@@ -261,6 +319,36 @@ type Func struct {
 	Name    string
 	Params  []*Var
 	Results []*Var
+
+	// TypeParams are type parameters of a generic function.  Types of
+	// those are constraints, like "any".
+	TypeParams []*Var
+
+	// Recv is a type of the receiver for methods, like "*Client".
+	Recv string
+
+	Pos token.Position
+
+	// InTestFile is true when the function is declared in a "_test.go"
+	// file.
+	InTestFile bool
+
+	// Generated is true when the function is declared in a generated file.
+	Generated bool
+
+	// Doc is the doc comment, without comment markers and directives.
+	Doc string
+
+	// Directives are directives in the doc comment.
+	Directives []*Directive
+
+	// Refs are references to package-level declarations in the signature
+	// and the body, available with Config.ScanBodies.  The receiver is not
+	// included.
+	Refs []*Ref
+
+	// Obj is a type-checked object, available with Config.TypeCheck.
+	Obj types.Object
 }
 
 // IsPublic checks its name is public or not.
@@ -288,17 +376,58 @@ type Type struct {
 	Name    string
 	Defined bool
 
+	// Pos is the position of the declaration.  It is valid only when the
+	// type is defined.
+	Pos token.Position
+
+	// Alias is true for alias declarations, like "type A = B".
+	Alias bool
+
+	// TypeParams are type parameters of a generic type.  Types of those
+	// are constraints, like "any".
+	TypeParams []*Var
+
+	// InTestFile is true when the type is defined in a "_test.go" file.
+	InTestFile bool
+
+	// Generated is true when the type is defined in a generated file.
+	Generated bool
+
+	// Expr is a string representation of the type expression in the
+	// declaration, like "int" or "map[string]any".  It is empty for struct
+	// and interface types.
+	Expr string
+
 	IsStruct    bool
 	IsInterface bool
 
 	Embeds   []string
 	embedIdx map[string]int
 
+	// EmbedFields are embedded fields of a struct type, which have no
+	// names.  Types of those are also in Embeds.
+	EmbedFields []*Field
+
 	Fields   []*Field
 	fieldIdx map[string]int
 
 	Methods   []*Func
 	methodIdx map[string]int
+
+	// Doc is the doc comment of the type.  The doc comment of a
+	// parenthesized declaration is used when the type has no own one.
+	Doc string
+
+	// Directives are directives in the doc comment and the line comment.
+	// Directives of a parenthesized declaration are shared by its types.
+	Directives []*Directive
+
+	// Refs are references to package-level declarations in the type
+	// definition, available with Config.ScanBodies.
+	Refs []*Ref
+
+	// Obj is a type-checked object, available with Config.TypeCheck.
+	Obj types.Object
 }
 
 func (typ *Type) putEmbed(typeName string) {
@@ -361,8 +490,7 @@ func (typ *Type) Method(n string) (*Func, bool) {
 // The query's format is "{tagName}" or "{tagName}:{value}".
 func (typ *Type) FieldsByTag(tagQuery string) []*Field {
 	var hits []*Field
-	var name string
-	var value *string
+	name, value := parseTagQuery(tagQuery)
 	for _, f := range typ.Fields {
 		if f.Tag != nil && f.Tag.match(name, value) {
 			hits = append(hits, f)
@@ -377,7 +505,51 @@ type Value struct {
 	Type    string
 	IsConst bool
 
+	Pos token.Position
+
+	// DeclPos is the position of the declaration of the value.  Values in
+	// a parenthesized declaration have same one.
+	DeclPos token.Position
+
+	// TypeExpr is a string representation of the type in the declaration,
+	// like "*Client" or "time.Duration".  While Type is the name of the
+	// base type in the package.
+	TypeExpr string
+
 	Literal *ast.BasicLit
+
+	// Expr is a string representation of the initial value, like "iota"
+	// or "1 << 10".  It is empty when the value is omitted, like constants
+	// which repeat the previous expression.
+	Expr string
+
+	// InTestFile is true when the value is declared in a "_test.go" file.
+	InTestFile bool
+
+	// Generated is true when the value is declared in a generated file.
+	Generated bool
+
+	// Doc is the doc comment of the value.  The doc comment of a
+	// parenthesized declaration is used when the value has no own one,
+	// then the line comment.
+	Doc string
+
+	// Comment is the line comment of the value.
+	Comment string
+
+	// Directives are directives in the doc comment and the line comment.
+	// Directives of a parenthesized declaration are shared by its values.
+	Directives []*Directive
+
+	// Embed is set for a variable which is populated by "//go:embed".
+	Embed *Embed
+
+	// Refs are references to package-level declarations in the type and
+	// the initial value, available with Config.ScanBodies.
+	Refs []*Ref
+
+	// Obj is a type-checked object, available with Config.TypeCheck.
+	Obj types.Object
 }
 
 // IsPublic checks its name is public or not.
@@ -0,0 +1,224 @@
+package srcdom
+
+import (
+	"bytes"
+	"go/ast"
+	"go/doc"
+	"go/printer"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TestKind is a kind of Test.
+type TestKind int
+
+// Kinds of Test.
+const (
+	TestFunc TestKind = iota + 1
+	BenchmarkFunc
+	FuzzFunc
+	ExampleFunc
+)
+
+var testKindNames = map[TestKind]string{
+	TestFunc:      "test",
+	BenchmarkFunc: "benchmark",
+	FuzzFunc:      "fuzz",
+	ExampleFunc:   "example",
+}
+
+func (k TestKind) String() string {
+	if s, ok := testKindNames[k]; ok {
+		return s
+	}
+	return "TestKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Test represents a test, benchmark, fuzz test or example function in a
+// "_test.go" file.
+type Test struct {
+	Kind TestKind
+
+	// Name is the name of the function, like "TestRead".
+	Name string
+
+	// Target is a name of the tested symbol, guessed from the function
+	// name.  Methods are named in the form of "{Type}.{Method}", and a
+	// suffix which starts with lower case is trimmed.  For example,
+	// "ExampleClient_Do_retry" targets "Client.Do".  It is empty for the
+	// package itself, like "Example".
+	Target string
+
+	// Output is expected output of an example, in its "// Output:" comment.
+	Output string
+
+	// HasOutput is true when the example has an output comment, even if
+	// the output is empty.  Examples without it are compiled but not run.
+	HasOutput bool
+
+	// Unordered is true when the output comment is "// Unordered output:".
+	Unordered bool
+
+	// Code is the formatted body of an example, without the output
+	// comment.  It is available only when Parser.Fset is set.
+	Code string
+
+	// File is a name of the file which has the function.
+	File string
+
+	Pos token.Position
+}
+
+// Tests returns tests in all files of the package, in order of files.
+func (p *Package) Tests() []*Test {
+	var tests []*Test
+	for _, f := range p.files {
+		tests = append(tests, f.Tests...)
+	}
+	return tests
+}
+
+var testPrefixes = []struct {
+	prefix string
+	kind   TestKind
+	param  string
+}{
+	{"Test", TestFunc, "*testing.T"},
+	{"Benchmark", BenchmarkFunc, "*testing.B"},
+	{"Fuzz", FuzzFunc, "*testing.F"},
+	{"Example", ExampleFunc, ""},
+}
+
+// scanTests collects tests in a "_test.go" file.
+func (p *Parser) scanTests(name string, file *ast.File) []*Test {
+	if !isTestFile(name) {
+		return nil
+	}
+	var examples map[string]*doc.Example
+	var tests []*Test
+	for _, decl := range file.Decls {
+		fun, ok := decl.(*ast.FuncDecl)
+		if !ok || fun.Recv != nil || fun.Type.TypeParams != nil {
+			continue
+		}
+		kind, rest, ok := p.testKind(fun)
+		if !ok {
+			continue
+		}
+		t := &Test{
+			Kind:   kind,
+			Name:   fun.Name.Name,
+			Target: testTarget(rest),
+			File:   name,
+			Pos:    p.position(fun.Name.Pos()),
+		}
+		if kind == ExampleFunc {
+			if examples == nil {
+				examples = map[string]*doc.Example{}
+				for _, ex := range doc.Examples(file) {
+					examples["Example"+ex.Name] = ex
+				}
+			}
+			if ex, ok := examples[t.Name]; ok {
+				t.Output = strings.TrimSpace(ex.Output)
+				t.HasOutput = ex.Output != "" || ex.EmptyOutput
+				t.Unordered = ex.Unordered
+				t.Code = p.exampleCode(ex)
+			}
+		}
+		tests = append(tests, t)
+	}
+	return tests
+}
+
+var outputCommentRx = regexp.MustCompile(`(?im)^[ \t]*//[ \t]*(unordered )?output:`)
+
+// exampleCode formats the body of an example.
+func (p *Parser) exampleCode(ex *doc.Example) string {
+	body, ok := ex.Code.(*ast.BlockStmt)
+	if !ok || p.Fset == nil {
+		return ""
+	}
+	var comments []*ast.CommentGroup
+	for _, g := range ex.Comments {
+		if body.Pos() <= g.Pos() && g.End() <= body.End() {
+			comments = append(comments, g)
+		}
+	}
+	var b bytes.Buffer
+	cfg := &printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	err := cfg.Fprint(&b, p.Fset, &printer.CommentedNode{Node: body, Comments: comments})
+	if err != nil {
+		return ""
+	}
+	s := strings.TrimSuffix(strings.TrimPrefix(b.String(), "{"), "}")
+	if loc := outputCommentRx.FindAllStringIndex(s, -1); len(loc) > 0 {
+		s = s[:loc[len(loc)-1][0]]
+	}
+	lines := strings.Split(strings.Trim(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "\t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// testKind determines a kind of the test function, by its name and its
+// signature like "go test" does.  It returns rest of the name after the
+// prefix.
+func (p *Parser) testKind(fun *ast.FuncDecl) (TestKind, string, bool) {
+	name := fun.Name.Name
+	if name == "TestMain" {
+		return 0, "", false
+	}
+	for _, tp := range testPrefixes {
+		rest, ok := strings.CutPrefix(name, tp.prefix)
+		if !ok || !isTestSuffix(rest) {
+			continue
+		}
+		params := fun.Type.Params.List
+		if fun.Type.Results != nil && len(fun.Type.Results.List) > 0 {
+			return 0, "", false
+		}
+		if tp.param == "" {
+			return tp.kind, rest, len(params) == 0
+		}
+		if len(params) != 1 || len(params[0].Names) > 1 {
+			return 0, "", false
+		}
+		if p.typeString(params[0].Type) != tp.param {
+			return 0, "", false
+		}
+		return tp.kind, rest, true
+	}
+	return 0, "", false
+}
+
+// isTestSuffix checks the rest of a test name doesn't start with a lower
+// case letter.  "Testing" is not a test.
+func isTestSuffix(s string) bool {
+	if s == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return !unicode.IsLower(r)
+}
+
+// testTarget converts the rest of a test name to the target symbol.
+func testTarget(rest string) string {
+	rest = strings.TrimPrefix(rest, "_")
+	if rest == "" {
+		return ""
+	}
+	var parts []string
+	for _, s := range strings.Split(rest, "_") {
+		if s == "" || !isTestSuffix(s) {
+			break
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ".")
+}
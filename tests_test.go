@@ -0,0 +1,89 @@
+package srcdom_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/koron-go/srcdom"
+)
+
+func TestTests(t *testing.T) {
+	pkg, err := srcdom.ReadSource("foo_test.go", []byte(`package foo
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMain(m *testing.M) {}
+
+func TestRead(t *testing.T) {}
+
+func TestClient_Do_retry(t *testing.T) {}
+
+func Testing(t *testing.T) {}
+
+func TestHelper(s string) {}
+
+func BenchmarkRead(b *testing.B) {}
+
+func FuzzParse(f *testing.F) {}
+
+func Example() {
+	fmt.Println("hello")
+	// Output: hello
+}
+
+func ExampleClient_Do() {
+	fmt.Println("b")
+	fmt.Println("a")
+	// Unordered output:
+	// a
+	// b
+}
+
+func ExampleNew_empty() {
+	// Output:
+}
+
+func ExampleNoOutput() {}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*srcdom.Test{
+		{Kind: srcdom.TestFunc, Name: "TestRead", Target: "Read"},
+		{Kind: srcdom.TestFunc, Name: "TestClient_Do_retry", Target: "Client.Do"},
+		{Kind: srcdom.BenchmarkFunc, Name: "BenchmarkRead", Target: "Read"},
+		{Kind: srcdom.FuzzFunc, Name: "FuzzParse", Target: "Parse"},
+		{Kind: srcdom.ExampleFunc, Name: "Example", Output: "hello", HasOutput: true, Code: `fmt.Println("hello")`},
+		{Kind: srcdom.ExampleFunc, Name: "ExampleClient_Do", Target: "Client.Do", Output: "a\nb", HasOutput: true, Unordered: true, Code: "fmt.Println(\"b\")\nfmt.Println(\"a\")"},
+		{Kind: srcdom.ExampleFunc, Name: "ExampleNew_empty", Target: "New", HasOutput: true},
+		{Kind: srcdom.ExampleFunc, Name: "ExampleNoOutput", Target: "NoOutput"},
+	}
+	got := pkg.Tests()
+	if d := cmp.Diff(want, got, cmpopts.IgnoreFields(srcdom.Test{}, "File", "Pos")); d != "" {
+		t.Errorf("tests mismatch: -want +got\n%s", d)
+	}
+	for _, test := range got {
+		if test.File != "foo_test.go" {
+			t.Errorf("unexpected file of %s: %s", test.Name, test.File)
+		}
+	}
+}
+
+func TestTestsNonTestFile(t *testing.T) {
+	pkg, err := srcdom.ReadSource("foo.go", []byte(`package foo
+
+import "testing"
+
+func TestRead(t *testing.T) {}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tests := pkg.Tests(); len(tests) != 0 {
+		t.Errorf("tests in non-test file: %+v", tests)
+	}
+}
@@ -0,0 +1,105 @@
+package srcdom
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+)
+
+// checkTypes type-checks files with go/types, and attaches results to pkg.
+// Failures of type-checking are recorded in pkg.TypeErrors, so pkg keeps
+// its syntactic information.
+func checkTypes(pkg *Package, fset *token.FileSet, files []*ast.File) {
+	if pkg == nil || len(files) == 0 {
+		return
+	}
+	conf := &types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			pkg.TypeErrors = append(pkg.TypeErrors, err)
+		},
+	}
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Implicits:  map[ast.Node]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+		Scopes:     map[ast.Node]*types.Scope{},
+	}
+	tpkg, _ := conf.Check(pkg.Name, fset, files, info)
+	if tpkg == nil {
+		return
+	}
+	pkg.TypesPackage = tpkg
+	pkg.TypesInfo = info
+	attachObjects(pkg, tpkg.Scope())
+}
+
+func attachObjects(pkg *Package, scope *types.Scope) {
+	for _, v := range pkg.Values {
+		v.Obj = scope.Lookup(v.Name)
+	}
+	for _, fn := range pkg.Funcs {
+		obj := scope.Lookup(fn.Name)
+		if obj == nil {
+			continue
+		}
+		fn.Obj = obj
+		if sig, ok := obj.Type().(*types.Signature); ok {
+			attachSignature(fn, sig)
+		}
+	}
+	for _, typ := range pkg.Types {
+		obj := scope.Lookup(typ.Name)
+		if obj == nil {
+			continue
+		}
+		typ.Obj = obj
+		attachTypeObjects(typ, obj.Type())
+	}
+}
+
+func attachTypeObjects(typ *Type, t types.Type) {
+	if named, ok := t.(*types.Named); ok {
+		for i := 0; i < named.NumMethods(); i++ {
+			m := named.Method(i)
+			if fn, ok := typ.Method(m.Name()); ok {
+				fn.Obj = m
+				attachSignature(fn, m.Type().(*types.Signature))
+			}
+		}
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			v := u.Field(i)
+			if f, ok := typ.Field(v.Name()); ok {
+				f.Obj = v
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < u.NumExplicitMethods(); i++ {
+			m := u.ExplicitMethod(i)
+			if fn, ok := typ.Method(m.Name()); ok {
+				fn.Obj = m
+				attachSignature(fn, m.Type().(*types.Signature))
+			}
+		}
+	}
+}
+
+func attachSignature(fn *Func, sig *types.Signature) {
+	attachTuple(fn.Params, sig.Params())
+	attachTuple(fn.Results, sig.Results())
+}
+
+func attachTuple(vars []*Var, tuple *types.Tuple) {
+	if tuple == nil || tuple.Len() != len(vars) {
+		return
+	}
+	for i, v := range vars {
+		v.Obj = tuple.At(i)
+	}
+}
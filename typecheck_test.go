@@ -0,0 +1,73 @@
+package srcdom_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/koron-go/srcdom"
+)
+
+func TestTypeCheck(t *testing.T) {
+	cfg := &srcdom.Config{TypeCheck: true}
+	pkg, err := cfg.ReadDir(filepath.Join("_testdata", "typecheck"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkg.TypeErrors) > 0 {
+		t.Fatalf("unexpected type errors: %v", pkg.TypeErrors)
+	}
+	if pkg.TypesPackage == nil || pkg.TypesInfo == nil {
+		t.Fatal("type-checked results are not attached")
+	}
+
+	typ, ok := pkg.Type("Client")
+	if !ok || typ.Obj == nil {
+		t.Fatalf("Client has no object: %+v", typ)
+	}
+	f, ok := typ.Field("Timeout")
+	if !ok || f.Obj == nil {
+		t.Fatalf("Client.Timeout has no object: %+v", f)
+	}
+	if got, want := f.Obj.Type().String(), "time.Duration"; got != want {
+		t.Errorf("unexpected type of Client.Timeout: want=%s got=%s", want, got)
+	}
+	m, ok := typ.Method("Do")
+	if !ok || m.Obj == nil {
+		t.Fatalf("Client.Do has no object: %+v", m)
+	}
+	if got, want := m.Params[0].Obj.Type().String(), "int"; got != want {
+		t.Errorf("unexpected type of Client.Do param: want=%s got=%s", want, got)
+	}
+
+	iface, _ := pkg.Type("Doer")
+	if im, ok := iface.Method("Do"); !ok || im.Obj == nil {
+		t.Errorf("Doer.Do has no object: %+v", im)
+	}
+	if fn, ok := pkg.Func("New"); !ok || fn.Obj == nil || fn.Results[0].Obj == nil {
+		t.Errorf("New has no object: %+v", fn)
+	}
+	if v, ok := pkg.Value("Max"); !ok || v.Obj == nil {
+		t.Errorf("Max has no object: %+v", v)
+	}
+}
+
+func TestTypeCheckFallback(t *testing.T) {
+	cfg := &srcdom.Config{TypeCheck: true}
+	pkg, err := cfg.ReadDir(filepath.Join("_testdata", "typeerror"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkg.TypeErrors) == 0 {
+		t.Fatal("type errors should be recorded")
+	}
+	typ, ok := pkg.Type("Foo")
+	if !ok {
+		t.Fatal("Foo should be read from syntax")
+	}
+	if f, ok := typ.Field("Bar"); !ok || f.Type != "Undefined" {
+		t.Errorf("unexpected field Foo.Bar: %+v", f)
+	}
+	if fn, ok := pkg.Func("Baz"); !ok || fn.Obj == nil {
+		t.Errorf("Baz should be type-checked: %+v", fn)
+	}
+}
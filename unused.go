@@ -0,0 +1,93 @@
+package srcdom
+
+import (
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Unused returns unexported types, funcs, consts, vars and struct fields,
+// which are never referenced in the package.  It requires
+// Config.ScanBodies.  Test files in the package are included when those
+// are read together.
+//
+// References are resolved syntactically, so this is conservative: a field
+// is used when any selector of its name can't be resolved, and all fields
+// are used by a composite literal without keys.  Methods, init and main
+// funcs and blank names are not reported.
+func (p *Package) Unused() []*Match {
+	used := map[string]bool{}
+	selectors := map[string]bool{}
+	unkeyed := map[string]bool{}
+	p.eachRefs(func(owner string, refs []*Ref) {
+		// references to itself, and to the receiver type from its methods,
+		// are not uses.
+		self, _, _ := strings.Cut(owner, ".")
+		for _, r := range refs {
+			if r.Unkeyed {
+				unkeyed[r.Name] = true
+			}
+			switch r.Kind {
+			case RefSelector:
+				selectors[r.Name] = true
+				continue
+			case RefUnresolved:
+				if _, sel, ok := strings.Cut(r.Name, "."); ok {
+					selectors[sel] = true
+				}
+				continue
+			}
+			if r.Name == owner || r.Name == self {
+				continue
+			}
+			used[r.Name] = true
+		}
+	})
+
+	var unused []*Match
+	add := func(n Node, name string, pos token.Position) {
+		unused = append(unused, &Match{Node: n, Name: name, Pos: pos})
+	}
+	for _, v := range p.Values {
+		if isUnusedName(v.Name) && !used[v.Name] {
+			add(v, v.Name, v.Pos)
+		}
+	}
+	for _, fn := range p.Funcs {
+		if fn.Name == "init" || fn.Name == "main" {
+			continue
+		}
+		if isUnusedName(fn.Name) && !used[fn.Name] {
+			add(fn, fn.Name, fn.Pos)
+		}
+	}
+	for _, typ := range p.Types {
+		if !typ.Defined {
+			continue
+		}
+		if isUnusedName(typ.Name) && !used[typ.Name] {
+			add(typ, typ.Name, typ.Pos)
+		}
+		for _, f := range typ.Fields {
+			name := typ.Name + "." + f.Name
+			if isUnusedName(f.Name) && !used[name] && !selectors[f.Name] && !unkeyed[typ.Name] {
+				add(f, name, f.Pos)
+			}
+		}
+	}
+	sort.SliceStable(unused, func(i, j int) bool {
+		return lessPosition(unused[i].Pos, unused[j].Pos)
+	})
+	return unused
+}
+
+func isUnusedName(name string) bool {
+	return name != "_" && name != "" && !isPublicName(name)
+}
+
+func lessPosition(a, b token.Position) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	return a.Offset < b.Offset
+}
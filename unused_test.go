@@ -0,0 +1,54 @@
+package srcdom_test
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func TestUnused(t *testing.T) {
+	cfg := &srcdom.Config{ScanBodies: true}
+	pkg, err := cfg.ReadDir("_testdata/unused", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range pkg.Unused() {
+		got = append(got, m.Name)
+	}
+	want := []string{
+		"client.unusedF",
+		"orphan",
+		"recursive",
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unused mismatch: -want +got\n%s", d)
+	}
+}
+
+func TestUnusedAfterUpdateFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo/a.go": {Data: []byte("package foo\n\nfunc helper() {}\n")},
+		"foo/b.go": {Data: []byte("package foo\n\nfunc Run() { helper() }\n")},
+	}
+	cfg := &srcdom.Config{ScanBodies: true}
+	pkg, err := cfg.ReadFS(fsys, "foo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo/b.go", "package foo\n\nfunc Run() {\n\thelper()\n}\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pkg.UpdateFile(fset, "foo/b.go", file); err != nil {
+		t.Fatal(err)
+	}
+	if unused := pkg.Unused(); len(unused) != 0 {
+		t.Errorf("helper should be used from b.go: %+v", unused[0])
+	}
+}
@@ -0,0 +1,93 @@
+package srcdom
+
+// Node is an element of the srcdom model.  All of *Package, *Import,
+// *Value, *Func, *Var, *Type, *Field and *Tag implement this.
+type Node interface {
+	node()
+}
+
+func (*Package) node() {}
+func (*Import) node()  {}
+func (*Value) node()   {}
+func (*Func) node()    {}
+func (*Var) node()     {}
+func (*Type) node()    {}
+func (*Field) node()   {}
+func (*Tag) node()     {}
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the srcdom model in depth-first order, like ast.Walk.
+// It starts by calling v.Visit(node); node must not be nil.  Children are
+// visited in the following order:
+//
+//   - Package: Imports, Values, Funcs, then Types
+//   - Type: EmbedFields, Fields, then Methods
+//   - Func: Params, then Results
+//   - Field: Tag
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *Package:
+		for _, x := range n.Imports {
+			Walk(v, x)
+		}
+		for _, x := range n.Values {
+			Walk(v, x)
+		}
+		for _, x := range n.Funcs {
+			Walk(v, x)
+		}
+		for _, x := range n.Types {
+			Walk(v, x)
+		}
+	case *Type:
+		for _, x := range n.EmbedFields {
+			Walk(v, x)
+		}
+		for _, x := range n.Fields {
+			Walk(v, x)
+		}
+		for _, x := range n.Methods {
+			Walk(v, x)
+		}
+	case *Func:
+		for _, x := range n.Params {
+			Walk(v, x)
+		}
+		for _, x := range n.Results {
+			Walk(v, x)
+		}
+	case *Field:
+		if n.Tag != nil {
+			Walk(v, n.Tag)
+		}
+	case *Import, *Value, *Var, *Tag:
+		// nothing to do
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the srcdom model in depth-first order, like
+// ast.Inspect.  It starts by calling f(node); node must not be nil.  If f
+// returns true, Inspect invokes f recursively for each of the children of
+// node, followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,101 @@
+package srcdom_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func TestInspect(t *testing.T) {
+	pkg, err := srcdom.ReadSource("foo.go", []byte(`package foo
+
+import "io"
+
+type Foo struct {
+	io.Reader
+	Name string `+"`json:\"name\"`"+`
+}
+
+func (f *Foo) Read(b []byte) (int, error) { return 0, nil }
+
+func New() *Foo { return nil }
+
+var X int
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	depth := 0
+	srcdom.Inspect(pkg, func(n srcdom.Node) bool {
+		if n == nil {
+			depth--
+			return false
+		}
+		var s string
+		switch n := n.(type) {
+		case *srcdom.Package:
+			s = "package " + n.Name
+		case *srcdom.Import:
+			s = "import " + n.Path
+		case *srcdom.Value:
+			s = "value " + n.Name
+		case *srcdom.Func:
+			s = "func " + n.Name
+		case *srcdom.Var:
+			s = "var " + n.Type
+		case *srcdom.Type:
+			s = "type " + n.Name
+		case *srcdom.Field:
+			s = "field " + n.Name
+			if n.Name == "" {
+				s = "embed " + n.Type
+			}
+		case *srcdom.Tag:
+			s = "tag " + n.Raw
+		default:
+			s = fmt.Sprintf("%T", n)
+		}
+		got = append(got, strings.Repeat("  ", depth)+s)
+		depth++
+		return true
+	})
+	want := []string{
+		"package foo",
+		"  import io",
+		"  value X",
+		"  func New",
+		"    var *Foo",
+		"  type Foo",
+		"    embed io.Reader",
+		"      tag ",
+		"    field Name",
+		`      tag json:"name"`,
+		"    func Read",
+		"      var []byte",
+		"      var int",
+		"      var error",
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unmatch traverse: -want +got\n%s", d)
+	}
+	if depth != 0 {
+		t.Errorf("unbalanced nil visits: %d", depth)
+	}
+
+	// stop traverse into types.
+	var n int
+	srcdom.Inspect(pkg, func(node srcdom.Node) bool {
+		if node != nil {
+			n++
+		}
+		_, isType := node.(*srcdom.Type)
+		return !isType
+	})
+	if n != 6 {
+		t.Errorf("unexpected number of nodes: want=6 got=%d", n)
+	}
+}
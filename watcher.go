@@ -0,0 +1,217 @@
+package srcdom
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event notifies changes of a package in a directory, which detected by
+// Watcher.
+type Event struct {
+	Dir string
+
+	// Package is the package after changes.  It is nil when the package
+	// was removed or failed to read.
+	Package *Package
+
+	Changes []*Change
+
+	// Err is an error which occurred while reading the package.
+	Err error
+}
+
+// Watcher watches a directory tree by polling, and keeps a Program live.
+// Only packages which have changed files are read again.
+type Watcher struct {
+	// Root is the root directory to watch.
+	Root string
+
+	// Loader is used to read packages.  The zero Loader is used when it
+	// is nil.
+	Loader *Loader
+
+	// Interval is an interval of polling.  It is 1 second when zero.
+	Interval time.Duration
+
+	mu     sync.Mutex
+	prog   *Program
+	stamps map[string]string
+}
+
+func (w *Watcher) loader() *Loader {
+	if w.Loader != nil {
+		return w.Loader
+	}
+	return &Loader{}
+}
+
+func (w *Watcher) interval() time.Duration {
+	if w.Interval > 0 {
+		return w.Interval
+	}
+	return time.Second
+}
+
+// Program returns the current snapshot of the Program.  Returned Program
+// should not be modified, and it is not updated by the watcher.
+func (w *Watcher) Program() *Program {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.prog
+}
+
+// Watch reads all packages under Root, then starts to watch those.  Events
+// are sent to the returned channel, which is closed when ctx is done.
+// Events should be received, otherwise watching is blocked.  When some
+// packages failed to read at first, the error is sent as the first event.
+func (w *Watcher) Watch(ctx context.Context) (<-chan *Event, error) {
+	dirs, err := listPackageDirs(w.Root)
+	if err != nil {
+		return nil, err
+	}
+	stamps, err := stampDirs(dirs)
+	if err != nil {
+		return nil, err
+	}
+	prog, loadErr := w.loader().Load(ctx, dirs...)
+	if prog == nil {
+		return nil, loadErr
+	}
+	w.mu.Lock()
+	w.prog = prog
+	w.stamps = stamps
+	w.mu.Unlock()
+
+	ch := make(chan *Event)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(w.interval())
+		defer ticker.Stop()
+		if loadErr != nil {
+			select {
+			case ch <- &Event{Dir: w.Root, Err: loadErr}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			for _, ev := range w.poll(ctx) {
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// poll checks stamps of directories, and reads changed packages again.
+func (w *Watcher) poll(ctx context.Context) []*Event {
+	dirs, err := listPackageDirs(w.Root)
+	if err != nil {
+		return []*Event{{Dir: w.Root, Err: err}}
+	}
+	stamps, err := stampDirs(dirs)
+	if err != nil {
+		return []*Event{{Dir: w.Root, Err: err}}
+	}
+
+	w.mu.Lock()
+	oldProg, oldStamps := w.prog, w.stamps
+	w.mu.Unlock()
+
+	var changed []string
+	for _, dir := range sortedStampDirs(oldStamps, stamps) {
+		if oldStamps[dir] != stamps[dir] {
+			changed = append(changed, dir)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	var events []*Event
+	prog := &Program{Module: oldProg.Module, Workspace: oldProg.Workspace}
+	for _, pkg := range oldProg.Packages {
+		prog.putPackage(pkg)
+	}
+	for _, dir := range changed {
+		oldPkg, _ := oldProg.Package(dir)
+		var newPkg *Package
+		if _, ok := stamps[dir]; ok {
+			p, err := w.loader().Load(ctx, dir)
+			if err != nil {
+				events = append(events, &Event{Dir: dir, Err: err})
+				// retry at next polling.
+				stamps[dir] = oldStamps[dir]
+				continue
+			}
+			newPkg, _ = p.Package(dir)
+		}
+		if newPkg == nil {
+			prog.removePackage(dir)
+		} else {
+			prog.putPackage(newPkg)
+		}
+		changes := comparePackages(oldPkg, newPkg)
+		if len(changes) == 0 {
+			continue
+		}
+		events = append(events, &Event{Dir: dir, Package: newPkg, Changes: changes})
+	}
+	prog.sortPackages()
+
+	w.mu.Lock()
+	w.prog = prog
+	w.stamps = stamps
+	w.mu.Unlock()
+	return events
+}
+
+// stampDirs makes stamps for directories from names, sizes and modified
+// times of ".go" files in each directory.
+func stampDirs(dirs []string) (map[string]string, error) {
+	stamps := make(map[string]string, len(dirs))
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		b := &strings.Builder{}
+		for _, d := range entries {
+			if d.IsDir() || !strings.HasSuffix(d.Name(), ".go") {
+				continue
+			}
+			fi, err := os.Stat(filepath.Join(dir, d.Name()))
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(b, "%s:%d:%d;", d.Name(), fi.Size(), fi.ModTime().UnixNano())
+		}
+		stamps[dir] = b.String()
+	}
+	return stamps, nil
+}
+
+func sortedStampDirs(a, b map[string]string) []string {
+	m := make(map[string]int, len(a)+len(b))
+	for k := range a {
+		m[k] = 0
+	}
+	for k := range b {
+		m[k] = 0
+	}
+	return sortedNames(m)
+}
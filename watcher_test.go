@@ -0,0 +1,129 @@
+package srcdom_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/koron-go/srcdom"
+)
+
+func waitEvent(t *testing.T, ch <-chan *srcdom.Event) *srcdom.Event {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatal("events channel is closed")
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout to wait an event")
+	}
+	return nil
+}
+
+func TestWatcher(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "foo")
+	if err := os.Mkdir(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(name, src string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("foo.go", "package foo\n\ntype Foo struct {\n\tA int\n\tB string\n}\n\nfunc (f *Foo) Do() {}\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &srcdom.Watcher{Root: root, Interval: 10 * time.Millisecond}
+	ch, err := w.Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := w.Program().Package(dir); !ok {
+		t.Fatal("initial package is not read")
+	}
+
+	writeFile("foo.go", "package foo\n\ntype Foo struct {\n\tA int\n\tC bool\n}\n\nfunc (f *Foo) Do(n int) error { return nil }\n\nfunc New() *Foo { return nil }\n")
+	ev := waitEvent(t, ch)
+	if ev.Err != nil {
+		t.Fatal(ev.Err)
+	}
+	if ev.Dir != dir {
+		t.Errorf("unexpected dir: want=%s got=%s", dir, ev.Dir)
+	}
+	want := []*srcdom.Change{
+		{Kind: srcdom.FuncAdded, Name: "New"},
+		{Kind: srcdom.FieldRemoved, Name: "Foo.B"},
+		{Kind: srcdom.FieldAdded, Name: "Foo.C"},
+		{Kind: srcdom.MethodChanged, Name: "Foo.Do", Old: "()", New: "(int) error"},
+	}
+	if d := cmp.Diff(want, ev.Changes); d != "" {
+		t.Errorf("unmatch changes: -want +got\n%s", d)
+	}
+	pkg, _ := w.Program().Package(dir)
+	if _, ok := pkg.Func("New"); !ok {
+		t.Error("program is not updated")
+	}
+
+	// add a new package.
+	dir2 := filepath.Join(root, "bar")
+	if err := os.Mkdir(dir2, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir2, "bar.go"), []byte("package bar\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	ev = waitEvent(t, ch)
+	if ev.Dir != dir2 || len(ev.Changes) != 1 || ev.Changes[0].Kind != srcdom.PackageAdded {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+
+	// remove the package.
+	if err := os.Remove(filepath.Join(dir, "foo.go")); err != nil {
+		t.Fatal(err)
+	}
+	ev = waitEvent(t, ch)
+	if ev.Dir != dir || ev.Package != nil || len(ev.Changes) != 1 || ev.Changes[0].Kind != srcdom.PackageRemoved {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+	if _, ok := w.Program().Package(dir); ok {
+		t.Error("removed package is in program")
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatcherInitialError(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"foo/foo.go": "package foo\n",
+		"bar/bar.go": "package bar\n\nfunc {\n",
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &srcdom.Watcher{Root: root, Interval: 10 * time.Millisecond}
+	ch, err := w.Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := w.Program().Package(filepath.Join(root, "foo")); !ok {
+		t.Error("readable package should be read")
+	}
+	ev := waitEvent(t, ch)
+	if ev.Err == nil || ev.Dir != root {
+		t.Errorf("error of initial reading should be sent: %+v", ev)
+	}
+
+	cancel()
+	for range ch {
+	}
+}